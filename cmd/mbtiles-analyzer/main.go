@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+var command = &cobra.Command{
+	Use:     "mbtiles-analyzer",
+	Long:    "Analyzes vector tiles of mbtiles file to build its metadata",
+	Args:    cobra.NoArgs,
+	Version: "0.0.1",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(nil)
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		if !viper.GetBool("verbose") {
+			logrus.SetLevel(logrus.WarnLevel)
+		}
+	},
+}
+
+// Scan vector layers command
+var layersCommand = &cobra.Command{
+	Use:   "layers",
+	Short: "Infer vector_layers metadata by scanning tiles",
+	Long:  "Prints layers with zoom levels they appear at and types of their fields",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, path := openManager()
+		layers, err := manager.ScanVectorLayers(context.Background(), mbtiles.TileFilter{})
+		if err != nil {
+			logrus.WithError(err).Fatal("Unable to scan vector layers")
+		}
+		output(struct {
+			VectorLayers []mbtiles.VectorLayer `json:"vector_layers"`
+		}{layers})
+
+		if viper.GetBool("write") {
+			writeMeta(manager, path, func(meta *mbtiles.Meta) {
+				meta.VectorLayers = layers
+			})
+		}
+	},
+}
+
+// Tile statistics command
+var tileStatsCommand = &cobra.Command{
+	Use:   "tilestats",
+	Short: "Generate tilestats of vector layers",
+	Long:  "Prints feature counts and geometry types of layers with value samples, ranges and distinct values counts of attributes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager, path := openManager()
+		stats, err := manager.TileStats(context.Background(), mbtiles.TileFilter{})
+		if err != nil {
+			logrus.WithError(err).Fatal("Unable to generate tilestats")
+		}
+		output(struct {
+			TileStats *mbtiles.TileStats `json:"tilestats"`
+		}{stats})
+
+		if viper.GetBool("write") {
+			writeMeta(manager, path, func(meta *mbtiles.Meta) {
+				meta.TileStats = stats
+			})
+		}
+	},
+}
+
+// Initializing options
+func init() {
+	command.PersistentFlags().BoolP("verbose", "v", false, "output details")
+	command.PersistentFlags().StringP("mbtiles", "d", "data/tiles-world-vector.mbtiles", "MBtiles data path")
+	command.PersistentFlags().BoolP("write", "w", false, "write the result into metadata table")
+
+	command.AddCommand(layersCommand)
+	command.AddCommand(tileStatsCommand)
+}
+
+// openManager of MBtiles file
+func openManager() (*mbtiles.Manager, string) {
+	path := viper.GetString("mbtiles")
+	manager, err := mbtiles.NewManager(path)
+	if err != nil {
+		logrus.WithError(err).Fatal("Unable to open mbtiles database")
+	}
+	return manager, path
+}
+
+// writeMeta updated by callback into the file, the manager is closed
+func writeMeta(manager *mbtiles.Manager, path string, update func(meta *mbtiles.Meta)) {
+	meta, err := manager.GetMeta()
+	if err != nil {
+		logrus.WithError(err).Fatal("Unable to read metadata")
+	}
+	update(meta)
+
+	// Opened database is immutable
+	if err := manager.Close(); err != nil {
+		logrus.WithError(err).Fatal("Unable to close mbtiles database")
+	}
+	if err := mbtiles.WriteMeta(path, meta); err != nil {
+		logrus.WithError(err).Fatal("Unable to write metadata")
+	}
+	logrus.WithField("mbtiles", path).Info("Write metadata")
+}
+
+// output JSON
+func output(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Fatal("Unable to generate JSON")
+	}
+	fmt.Println(string(data))
+}
+
+// main command
+func main() {
+	// Bind all flags
+	for _, flags := range []*pflag.FlagSet{
+		command.PersistentFlags(),
+	} {
+		if err := viper.BindPFlags(flags); err != nil {
+			logrus.WithError(err).Fatal("Unable to bind command line flags")
+		}
+	}
+
+	// Handle environment variables
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	// Read settings from config file
+	viper.AddConfigPath(".")
+	viper.SetConfigName("config")
+
+	// Get YAML
+	if err := viper.ReadInConfig(); err != nil {
+		// Don't fail if config not found
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logrus.WithError(err).Warn("Unable to read config file")
+		}
+	}
+
+	// Pass control
+	if err := command.Execute(); err != nil {
+		logrus.WithError(err).Fatal("Failed to execute command")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"log"
+	"runtime"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -30,17 +31,18 @@ var command = &cobra.Command{
 			Path:       viper.GetString("export"),
 			Decompress: viper.GetBool("decompress"),
 			BaseUrl:    viper.GetString("url"),
+			Workers:    viper.GetInt("workers"),
 		}
 
 		mbtilesPath := viper.GetString("import")
 		exporter, err := mbtiles.NewExporter(mbtilesPath, settings)
 		if err != nil {
-			logrus.WithError(err).Error("Export tiles")
+			logrus.WithError(err).Fatal("Export tiles")
 		}
 
 		logrus.WithField("import", mbtilesPath).Infof("Start export")
 		if err = exporter.Export(); err != nil {
-			logrus.WithError(exporter.Export()).Error("Export tiles")
+			logrus.WithError(err).Error("Export tiles")
 		}
 		logrus.WithField("import", mbtilesPath).Infof("End export")
 	},
@@ -52,6 +54,7 @@ func init() {
 	command.Flags().StringP("export", "o", "tiles", "Export data path")
 	command.Flags().StringP("url", "u", "http://localhost/tiles/", "base URL to serve tiles")
 	command.Flags().BoolP("decompress", "d", true, "Decompress PBF files")
+	command.Flags().IntP("workers", "w", runtime.NumCPU(), "Number of concurrent tile writers")
 	command.Flags().BoolP("verbose", "v", false, "Output details")
 }
 
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 
 	"github.com/gopherjs/gopherjs/js"
-	"github.com/paulmach/orb/geojson"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
@@ -35,15 +34,10 @@ func search(db string, subject string, maxResults string) ([]byte, error) {
 		logrus.WithError(err).Fatal("Unable to open mbtiles database")
 	}
 
-	features, err := manager.Search(viper.GetString("search"), viper.GetInt("max"))
+	places, err := manager.Search(viper.GetString("search"), viper.GetInt("max"))
 	if err != nil {
 		logrus.WithError(err).Fatal("Unable to search database")
 	}
 
-	fc := &geojson.FeatureCollection{
-		Type:     "FeatureCollection",
-		Features: features,
-	}
-
-	return json.Marshal(fc)
+	return json.Marshal(places.FeatureCollection())
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// Batch formats
+const (
+	CSVFormat    = "csv"
+	NDJSONFormat = "ndjson"
+)
+
+// batchColumns added to each row
+var batchColumns = []string{"lon", "lat", "matched_name", "matched_class", "score"}
+
+// BatchSettings of geocoding
+type BatchSettings struct {
+	// Input and output format, CSVFormat or NDJSONFormat
+	Format string
+
+	// Column of query in CSV header or key of NDJSON object
+	Column string
+
+	// Number of parallel lookups
+	Workers int
+}
+
+// batchRow of input with its lookup result
+type batchRow struct {
+	query  string
+	record []string
+	object map[string]interface{}
+	place  *mbtiles.Place
+	err    error
+	done   chan struct{}
+}
+
+// batchFormat by file extension, CSV by default
+func batchFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl", ".json":
+		return NDJSONFormat
+	}
+	return CSVFormat
+}
+
+// Batch geocodes every input row by the best search result.
+// Rows are written in input order with lon, lat, matched name, class and score added.
+func Batch(manager *mbtiles.Manager, input io.Reader, output io.Writer, settings BatchSettings) error {
+	if settings.Workers < 1 {
+		settings.Workers = 1
+	}
+	var read func(emit func(row *batchRow) bool) error
+	var write func(row *batchRow) error
+	var flush func() error
+	switch settings.Format {
+	case CSVFormat:
+		reader := csv.NewReader(input)
+		writer := csv.NewWriter(output)
+		header, err := reader.Read()
+		if err != nil {
+			return err
+		}
+		column := -1
+		for i, name := range header {
+			if name == settings.Column {
+				column = i
+			}
+		}
+		if column < 0 {
+			return fmt.Errorf("query column %q not found", settings.Column)
+		}
+		if err := writer.Write(append(header, batchColumns...)); err != nil {
+			return err
+		}
+		read = func(emit func(row *batchRow) bool) error {
+			for {
+				record, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if !emit(&batchRow{query: record[column], record: record}) {
+					return nil
+				}
+			}
+		}
+		write = func(row *batchRow) error {
+			record := row.record
+			for _, value := range batchValues(row.place) {
+				switch v := value.(type) {
+				case float64:
+					record = append(record, strconv.FormatFloat(v, 'f', -1, 64))
+				case string:
+					record = append(record, v)
+				default:
+					record = append(record, "")
+				}
+			}
+			return writer.Write(record)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+
+	case NDJSONFormat:
+		decoder := json.NewDecoder(input)
+		decoder.UseNumber()
+		writer := bufio.NewWriter(output)
+		encoder := json.NewEncoder(writer)
+		read = func(emit func(row *batchRow) bool) error {
+			for {
+				var object map[string]interface{}
+				err := decoder.Decode(&object)
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				query, _ := object[settings.Column].(string)
+				if !emit(&batchRow{query: query, object: object}) {
+					return nil
+				}
+			}
+		}
+		write = func(row *batchRow) error {
+			for i, value := range batchValues(row.place) {
+				row.object[batchColumns[i]] = value
+			}
+			return encoder.Encode(row.object)
+		}
+		flush = writer.Flush
+
+	default:
+		return fmt.Errorf("unknown batch format %q", settings.Format)
+	}
+
+	// Rows are looked up in parallel and written in input order.
+	// Reading stops on the first error.
+	jobs := make(chan *batchRow)
+	pending := make(chan *batchRow, settings.Workers*4)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < settings.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range jobs {
+				row.place, row.err = geocodeRow(manager, row.query)
+				close(row.done)
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		readErr = read(func(row *batchRow) bool {
+			row.done = make(chan struct{})
+			select {
+			case pending <- row:
+			case <-stop:
+				return false
+			}
+			select {
+			case jobs <- row:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	}()
+
+	var writeErr error
+	for row := range pending {
+		<-row.done
+		if writeErr = row.err; writeErr == nil {
+			writeErr = write(row)
+		}
+		if writeErr != nil {
+			break
+		}
+	}
+	if writeErr != nil {
+		// Pending rows may never be looked up
+		close(stop)
+		for range pending {
+		}
+	}
+	wg.Wait()
+	if writeErr != nil {
+		return writeErr
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return flush()
+}
+
+// geocodeRow by the most relevant search result, nil if nothing is found
+func geocodeRow(manager *mbtiles.Manager, query string) (*mbtiles.Place, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	places, err := geocode(manager, query, mbtiles.SearchOptions{Limit: 1})
+	if err != nil || len(places) == 0 {
+		return nil, err
+	}
+	return places[0], nil
+}
+
+// batchValues of batch columns, nil values if nothing is found
+func batchValues(place *mbtiles.Place) []interface{} {
+	if place == nil {
+		return make([]interface{}, len(batchColumns))
+	}
+	return []interface{}{
+		place.Center.Lon(),
+		place.Center.Lat(),
+		place.Name,
+		place.Class,
+		place.Score,
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+func TestBatch(t *testing.T) {
+	manager := newTestManager(t)
+	for name, test := range map[string]struct {
+		settings BatchSettings
+		input    string
+		matched  []string
+	}{
+		"csv": {
+			BatchSettings{Format: CSVFormat, Column: "q", Workers: 1},
+			"id,q\n1,ursula\n2,nowhere\n3,\n",
+			[]string{"Santa Úrsula", "", ""},
+		},
+		"ndjson": {
+			BatchSettings{Format: NDJSONFormat, Column: "q", Workers: 2},
+			`{"id":1,"q":"laguna"}` + "\n" + `{"id":2,"q":"nowhere"}` + "\n",
+			[]string{"San Cristóbal de La Laguna", ""},
+		},
+	} {
+		var output bytes.Buffer
+		require.NoError(t, Batch(manager, strings.NewReader(test.input), &output, test.settings), name)
+
+		var matched []string
+		if test.settings.Format == CSVFormat {
+			records, err := csv.NewReader(&output).ReadAll()
+			require.NoError(t, err, name)
+			require.Equal(t, []string{"id", "q", "lon", "lat", "matched_name", "matched_class", "score"}, records[0])
+			for _, record := range records[1:] {
+				matched = append(matched, record[4])
+			}
+		} else {
+			decoder := json.NewDecoder(&output)
+			for decoder.More() {
+				var object map[string]interface{}
+				require.NoError(t, decoder.Decode(&object), name)
+				require.Contains(t, object, "lon")
+				require.Contains(t, object, "id")
+				value, _ := object["matched_name"].(string)
+				matched = append(matched, value)
+			}
+		}
+		require.Equal(t, test.matched, matched, name)
+	}
+}
+
+func TestBatchOrder(t *testing.T) {
+	manager := newTestManager(t)
+	queries := []string{"santa cruz", "laguna", "ursula", "puerto", "nowhere"}
+	var input strings.Builder
+	input.WriteString("q\n")
+	for i := 0; i < 50; i++ {
+		input.WriteString(queries[i%len(queries)] + "\n")
+	}
+
+	// Parallel lookups are written in input order
+	var output bytes.Buffer
+	require.NoError(t, Batch(manager, strings.NewReader(input.String()), &output, BatchSettings{
+		Format: CSVFormat, Column: "q", Workers: 8,
+	}))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 51)
+	for i, line := range lines[1:] {
+		require.True(t, strings.HasPrefix(line, queries[i%len(queries)]+","), line)
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	manager := newTestManager(t)
+
+	// Malformed row
+	err := Batch(manager, strings.NewReader("{\"q\":\"laguna\"}\n{\"q\":\n"), io.Discard, BatchSettings{
+		Format: NDJSONFormat, Column: "q", Workers: 2,
+	})
+	require.Error(t, err)
+
+	// Missing column
+	err = Batch(manager, strings.NewReader("name\nlaguna\n"), io.Discard, BatchSettings{Format: CSVFormat, Column: "q"})
+	require.Error(t, err)
+
+	// Reading stops on the first write error
+	input := &countingReader{Reader: strings.NewReader("q\n" + strings.Repeat("laguna\n", 100000))}
+	err = Batch(manager, input, failingWriter{}, BatchSettings{Format: CSVFormat, Column: "q", Workers: 4})
+	require.ErrorIs(t, err, errWrite)
+	require.Less(t, input.count, 100000*len("laguna\n")/2)
+
+	// Lookup errors fail the batch
+	cfg := manager.SearchConfig()
+	cfg.Mode = "unknown"
+	manager.SetSearchConfig(cfg)
+	err = Batch(manager, strings.NewReader("q\nlaguna\n"), io.Discard, BatchSettings{Format: CSVFormat, Column: "q"})
+	require.ErrorIs(t, err, mbtiles.ErrUnknownSearchMode)
+}
+
+// errWrite of failingWriter
+var errWrite = errors.New("write failed")
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+// countingReader of read bytes
+type countingReader struct {
+	io.Reader
+	count int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count += n
+	return n, err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// testPlaces of Tenerife in OpenMapTiles place layer
+var testPlaces = []struct {
+	point orb.Point
+	name  string
+	class string
+}{
+	{orb.Point{-16.2518, 28.4636}, "Santa Cruz de Tenerife", "city"},
+	{orb.Point{-16.3159, 28.4853}, "San Cristóbal de La Laguna", "city"},
+	{orb.Point{-16.4891, 28.4260}, "Santa Úrsula", "town"},
+	{orb.Point{-16.5490, 28.4142}, "Puerto de la Cruz", "town"},
+}
+
+// newTestManager of test places written into a new MBTiles file
+func newTestManager(t *testing.T) *mbtiles.Manager {
+	const zoom = 14
+	tiles := map[maptile.Tile]*geojson.FeatureCollection{}
+	for i, place := range testPlaces {
+		tile := maptile.At(place.point, zoom)
+		if tiles[tile] == nil {
+			tiles[tile] = geojson.NewFeatureCollection()
+		}
+		feature := geojson.NewFeature(place.point)
+		feature.ID = float64(i + 1)
+		feature.Properties = geojson.Properties{"name": place.name, "class": place.class, "rank": float64(i + 1)}
+		tiles[tile].Append(feature)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	writer, err := mbtiles.NewWriter(path, mbtiles.WriterSettings{})
+	require.NoError(t, err)
+	for tile, fc := range tiles {
+		layers := mvt.NewLayers(map[string]*geojson.FeatureCollection{"place": fc})
+		layers.ProjectToTile(tile)
+		data, err := mvt.MarshalGzipped(layers)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteTile(zoom, int64(tile.X), mbtiles.FlipRow(zoom, int64(tile.Y)), data))
+	}
+	require.NoError(t, writer.WriteMeta(&mbtiles.Meta{Name: "test", Format: "pbf", MaxZoom: zoom}))
+	require.NoError(t, writer.Close())
+
+	manager, err := mbtiles.NewManager(path)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = manager.Close()
+	})
+	return manager
+}
@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
 	"strings"
 
-	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
 	"github.com/eslider/geo-tools/pkg/mbtiles"
@@ -20,47 +25,260 @@ var command = &cobra.Command{
 	Long:    "Geocode by using mbtiles file",
 	Args:    cobra.NoArgs,
 	Version: "0.0.1",
-	Run: func(cmd *cobra.Command, args []string) {
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		log.SetOutput(nil)
 		logrus.SetFormatter(&logrus.JSONFormatter{})
 		if !viper.GetBool("verbose") {
 			logrus.SetLevel(logrus.WarnLevel | logrus.ErrorLevel | logrus.DebugLevel | logrus.FatalLevel | logrus.PanicLevel)
 		}
-		manager, err := mbtiles.NewManager(viper.GetString("mbtiles"))
-		if err != nil {
-			logrus.WithError(err).Fatal("Unable to open mbtiles database")
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := openManager()
+
+		var places mbtiles.Places
+		var err error
+		if reverse := viper.GetString("reverse"); reverse != "" {
+			lon, lat, err := parseLonLat(reverse)
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to parse reverse point")
+			}
+			places, err = manager.Reverse(lon, lat, mbtiles.ReverseOptions{Limit: viper.GetInt("max")})
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to reverse geocode")
+			}
+		} else if suggest := viper.GetString("suggest"); suggest != "" {
+			places, err = manager.Suggest(suggest, viper.GetInt("max"))
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to suggest places")
+			}
+		} else {
+			opts := mbtiles.SearchOptions{Limit: viper.GetInt("max")}
+			if near := viper.GetString("near"); near != "" {
+				lon, lat, err := parseLonLat(near)
+				if err != nil {
+					logrus.WithError(err).Fatal("Unable to parse focus point")
+				}
+				opts.Focus = &orb.Point{lon, lat}
+			}
+			if bbox := viper.GetString("bbox"); bbox != "" {
+				bound, err := parseBound(bbox)
+				if err != nil {
+					logrus.WithError(err).Fatal("Unable to parse bounding box")
+				}
+				opts.Bound = &bound
+			}
+			if categories := viper.GetStringSlice("categories"); len(categories) > 0 || viper.GetBool("poi") {
+				places, err = manager.SearchPOI(viper.GetString("search"), categories, opts)
+			} else {
+				places, err = geocode(manager, viper.GetString("search"), opts)
+			}
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to search database")
+			}
 		}
 
-		features, err := manager.Search(viper.GetString("search"), viper.GetInt("max"))
+		geoJSON, err := json.Marshal(places.FeatureCollection())
 		if err != nil {
-			logrus.WithError(err).Fatal("Unable to search database")
+			logrus.WithError(err).Fatal("Unable to generate GeoJSON")
 		}
+		fmt.Print(string(geoJSON))
+	},
+}
+
+// Build place index command
+var indexCommand = &cobra.Command{
+	Use:   "index",
+	Short: "Build place index to speed up searching",
+	Long:  "Writes place names, classes and coordinates into SQLite FTS5 sidecar database",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		mbtilesPath := viper.GetString("mbtiles")
+		manager := openManager()
 
-		fc := &geojson.FeatureCollection{
-			Type:     "FeatureCollection",
-			Features: features,
+		indexPath := viper.GetString("index")
+		if indexPath == "" {
+			indexPath = mbtiles.IndexPath(mbtilesPath)
 		}
-		geoJSON, err := json.Marshal(fc)
+		if err := manager.BuildPlaceIndex(indexPath); err != nil {
+			logrus.WithError(err).Fatal("Unable to build place index")
+		}
+	},
+}
+
+// Serve geocoding API command
+var serveCommand = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve Nominatim compatible geocoding API",
+	Long:  "Serves /search?q= and /reverse?lat=&lon= endpoints with json, jsonv2 and geojson formats",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		server := NewServer(openManager(), viper.GetInt("limit"), viper.GetString("cors"))
+
+		listen := viper.GetString("listen")
+		logrus.WithField("listen", listen).Info("Start server")
+		if err := http.ListenAndServe(listen, server); err != nil {
+			logrus.WithError(err).Fatal("Serve geocoding API")
+		}
+	},
+}
+
+// Batch geocoding command
+var batchCommand = &cobra.Command{
+	Use:   "batch",
+	Short: "Geocode CSV or NDJSON rows",
+	Long:  "Adds lon, lat, matched_name, matched_class and score of the best search result to every input row",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manager := openManager()
+
+		input := os.Stdin
+		inputPath := viper.GetString("input")
+		if inputPath != "" && inputPath != "-" {
+			file, err := os.Open(inputPath)
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to open input file")
+			}
+			defer file.Close()
+			input = file
+		}
+
+		format := viper.GetString("format")
+		if format == "" {
+			format = batchFormat(inputPath)
+		}
+		err := Batch(manager, input, os.Stdout, BatchSettings{
+			Format:  format,
+			Column:  viper.GetString("column"),
+			Workers: viper.GetInt("workers"),
+		})
 		if err != nil {
-			logrus.WithError(err).Fatal("Unable to generate GeoJSON")
+			logrus.WithError(err).Fatal("Unable to geocode batch")
 		}
-		fmt.Print(string(geoJSON))
 	},
 }
 
+// defaultSearchConfig of searchable layers and properties
+var defaultSearchConfig = mbtiles.DefaultSearchConfig()
+
 // Initializing options
 func init() {
-	command.Flags().BoolP("verbose", "v", false, "output details")
-	command.Flags().StringP("mbtiles", "d", "data/canary-islands-latest.mbtiles", "MBtiles data path")
+	command.PersistentFlags().BoolP("verbose", "v", false, "output details")
+	command.PersistentFlags().StringP("mbtiles", "d", "data/canary-islands-latest.mbtiles", "MBtiles data path")
+	command.PersistentFlags().StringSlice("layers", defaultSearchConfig.Layers, "searchable layers")
+	command.PersistentFlags().StringSlice("name-keys", defaultSearchConfig.NameKeys, "name properties matched by search")
+	command.PersistentFlags().StringSlice("lang", defaultSearchConfig.Languages, "languages of displayed names by priority, e.g. `es,en,latin`")
+	command.PersistentFlags().StringSlice("class-keys", defaultSearchConfig.ClassKeys, "class properties by priority")
+	command.PersistentFlags().Bool("context", defaultSearchConfig.Context, "add administrative context and display name to results")
+	command.PersistentFlags().StringSlice("context-layers", defaultSearchConfig.ContextLayers, "layers of administrative polygons or boundary lines with admin_level property")
+	command.PersistentFlags().Int("zoom", 0, "zoom level of tiles to search, tileset maxzoom by default")
+	command.PersistentFlags().String("mode", string(defaultSearchConfig.Mode), "names matching mode: exact, fuzzy or phonetic")
+	command.PersistentFlags().Float64("fuzzy-threshold", defaultSearchConfig.FuzzyThreshold, "minimal names similarity from 0 to 1 in fuzzy mode")
 	command.Flags().StringP("search", "s", "", "search query")
 	command.Flags().Int("max", 5, "maximal results number")
+	command.Flags().String("near", "", "boost places near `lon,lat` focus point")
+	command.Flags().String("bbox", "", "restrict places to `minLon,minLat,maxLon,maxLat` bounding box")
+	command.Flags().Bool("poi", false, "search points of interest of poi layer")
+	command.Flags().StringSlice("categories", nil, "POI classes or subclasses, e.g. pharmacy,restaurant, implies --poi")
+	command.Flags().String("suggest", "", "suggest places by name `prefix` while typing, the prefix index of all places is built for one query")
+	command.Flags().String("reverse", "", "reverse geocode `lon,lat` point")
+
+	indexCommand.Flags().String("index", "", "place index path, next to MBtiles file by default")
+	command.AddCommand(indexCommand)
+
+	serveCommand.Flags().StringP("listen", "l", ":8080", "HTTP listen address")
+	serveCommand.Flags().String("cors", "*", "Access-Control-Allow-Origin header value, empty to disable")
+	serveCommand.Flags().Int("limit", 10, "default search results number")
+	command.AddCommand(serveCommand)
+
+	batchCommand.Flags().StringP("input", "i", "", "CSV or NDJSON input path, stdin by default")
+	batchCommand.Flags().String("format", "", "input and output format: csv or ndjson, by input extension by default")
+	batchCommand.Flags().String("column", "name", "query column of CSV header or key of NDJSON objects")
+	batchCommand.Flags().IntP("workers", "w", runtime.NumCPU(), "number of parallel lookups")
+	command.AddCommand(batchCommand)
+}
+
+// openManager of MBtiles file with search configuration
+func openManager() *mbtiles.Manager {
+	manager, err := mbtiles.NewManager(viper.GetString("mbtiles"))
+	if err != nil {
+		logrus.WithError(err).Fatal("Unable to open mbtiles database")
+	}
+
+	var searchConfig mbtiles.SearchConfig
+	if err := viper.Unmarshal(&searchConfig); err != nil {
+		logrus.WithError(err).Fatal("Unable to read search configuration")
+	}
+	manager.SetSearchConfig(searchConfig)
+	return manager
+}
+
+// geocode addresses with house numbers, then places
+func geocode(manager *mbtiles.Manager, query string, opts mbtiles.SearchOptions) (mbtiles.Places, error) {
+	if _, ok := mbtiles.ParseAddress(query); ok {
+		places, err := manager.SearchAddress(query, mbtiles.AddressOptions{
+			Limit: opts.Limit,
+			Focus: opts.Focus,
+			Bound: opts.Bound,
+		})
+		if err != nil || len(places) > 0 {
+			return places, err
+		}
+	}
+	return manager.SearchWithOptions(query, opts)
+}
+
+// parseLonLat from "lon,lat" string
+func parseLonLat(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("%q isn't lon,lat pair", value)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return lon, lat, nil
+}
+
+// parseBound from "minLon,minLat,maxLon,maxLat" string
+func parseBound(value string) (orb.Bound, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return orb.Bound{}, fmt.Errorf("%q isn't minLon,minLat,maxLon,maxLat box", value)
+	}
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return orb.Bound{}, err
+		}
+		coordinates[i] = coordinate
+	}
+
+	// Corners may be given in any order
+	return orb.MultiPoint{
+		{coordinates[0], coordinates[1]},
+		{coordinates[2], coordinates[3]},
+	}.Bound(), nil
 }
 
 // main command
 func main() {
 	// Bind all flags
-	if err := viper.BindPFlags(command.Flags()); err != nil {
-		logrus.WithError(err).Fatal("Unable to bind command line flags")
+	for _, flags := range []*pflag.FlagSet{
+		command.PersistentFlags(),
+		command.Flags(),
+		indexCommand.Flags(),
+		serveCommand.Flags(),
+		batchCommand.Flags(),
+	} {
+		if err := viper.BindPFlags(flags); err != nil {
+			logrus.WithError(err).Fatal("Unable to bind command line flags")
+		}
 	}
 
 	// Handle environment variables
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb/geojson"
+	"github.com/sirupsen/logrus"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// licence of served data
+const licence = "Data © OpenStreetMap contributors, ODbL 1.0. https://osm.org/copyright"
+
+// maxLimit of search results number
+const maxLimit = 40
+
+// Server of Nominatim compatible search and reverse geocoding API
+type Server struct {
+	manager *mbtiles.Manager
+
+	// Default results number of search
+	limit int
+
+	// Value of Access-Control-Allow-Origin header, disabled if empty
+	cors string
+}
+
+// nominatimPlace of json and jsonv2 response formats,
+// OSM ids and ranks aren't known to vector tiles, so tile values are exposed under their own names
+type nominatimPlace struct {
+	FeatureID   *int64   `json:"feature_id,omitempty"`
+	Licence     string   `json:"licence"`
+	Lat         string   `json:"lat"`
+	Lon         string   `json:"lon"`
+	Class       string   `json:"class,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Type        string   `json:"type"`
+	Rank        *int     `json:"rank,omitempty"`
+	Importance  float64  `json:"importance"`
+	AddressType string   `json:"addresstype,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	DisplayName string   `json:"display_name"`
+	BoundingBox []string `json:"boundingbox"`
+
+	// Address details of jsonv2 format by context classes
+	Address map[string]string `json:"address,omitempty"`
+}
+
+// NewServer of geocoding API
+func NewServer(manager *mbtiles.Manager, limit int, cors string) *Server {
+	return &Server{manager: manager, limit: limit, cors: cors}
+}
+
+// ServeHTTP routes requests to search or reverse geocoding
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cors != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.cors)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/search", "/search.php":
+		s.serveSearch(w, r)
+	case "/reverse", "/reverse.php":
+		s.serveReverse(w, r)
+	case "/status", "/status.php":
+		_, _ = w.Write([]byte("OK"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveSearch of q parameter
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if !isFormatSupported(format) {
+		writeError(w, http.StatusBadRequest, "Parameter 'format' must be one of: json, jsonv2, geojson")
+		return
+	}
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "Parameter 'q' is required")
+		return
+	}
+	limit := s.limit
+	if value, err := strconv.Atoi(query.Get("limit")); err == nil && value > 0 && value <= maxLimit {
+		limit = value
+	}
+
+	// Places are restricted to the viewbox if it's bounded, otherwise boosted near its center
+	opts := mbtiles.SearchOptions{Limit: limit}
+	if viewbox := query.Get("viewbox"); viewbox != "" {
+		bound, err := parseBound(viewbox)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Bad parameter 'viewbox'. Expected 4 coordinates.")
+			return
+		}
+		if query.Get("bounded") == "1" {
+			opts.Bound = &bound
+		} else {
+			center := bound.Center()
+			opts.Focus = &center
+		}
+	}
+
+	places, err := geocode(s.manager, q, opts)
+	if err != nil {
+		logrus.WithError(err).Error("Unable to search database")
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	writePlaces(w, format, places)
+}
+
+// serveReverse of lat and lon parameters, the nearest place is returned
+func (s *Server) serveReverse(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if !isFormatSupported(format) {
+		writeError(w, http.StatusBadRequest, "Parameter 'format' must be one of: json, jsonv2, geojson")
+		return
+	}
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		writeError(w, http.StatusBadRequest, "Floating-point number expected for parameter 'lat' and 'lon'")
+		return
+	}
+
+	places, err := s.manager.Reverse(lon, lat, mbtiles.ReverseOptions{Limit: 1})
+	if err != nil {
+		logrus.WithError(err).Error("Unable to reverse geocode")
+		writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	if len(places) == 0 {
+		writeJSON(w, map[string]string{"error": "Unable to geocode"})
+		return
+	}
+	if format == "geojson" {
+		writePlaces(w, format, places[:1])
+		return
+	}
+	writeJSON(w, newNominatimPlace(places[0], format == "jsonv2"))
+}
+
+// isFormatSupported of response, json by default
+func isFormatSupported(format string) bool {
+	switch format {
+	case "", "json", "jsonv2", "geojson":
+		return true
+	}
+	return false
+}
+
+// writePlaces list in response format
+func writePlaces(w http.ResponseWriter, format string, places mbtiles.Places) {
+	if format == "geojson" {
+		fc := geojson.NewFeatureCollection()
+		fc.ExtraMembers = geojson.Properties{"licence": licence}
+		for _, place := range places {
+			fc.Append(newNominatimFeature(place))
+		}
+		writeJSON(w, fc)
+		return
+	}
+
+	result := []*nominatimPlace{}
+	for _, place := range places {
+		result = append(result, newNominatimPlace(place, format == "jsonv2"))
+	}
+	writeJSON(w, result)
+}
+
+// newNominatimPlace of json or jsonv2 format
+func newNominatimPlace(place *mbtiles.Place, v2 bool) *nominatimPlace {
+	result := &nominatimPlace{
+		FeatureID:   featureID(place),
+		Licence:     licence,
+		Lat:         formatCoordinate(place.Center.Lat()),
+		Lon:         formatCoordinate(place.Center.Lon()),
+		Type:        place.Class,
+		Importance:  place.Score,
+		DisplayName: place.DisplayName,
+		BoundingBox: []string{
+			formatCoordinate(place.Bound.Min.Lat()),
+			formatCoordinate(place.Bound.Max.Lat()),
+			formatCoordinate(place.Bound.Min.Lon()),
+			formatCoordinate(place.Bound.Max.Lon()),
+		},
+	}
+	if result.DisplayName == "" {
+		result.DisplayName = place.Name
+	}
+	if result.Type == "" {
+		result.Type = "yes"
+	}
+	if !v2 {
+		result.Class = place.Layer
+		return result
+	}
+	result.Category = place.Layer
+	result.AddressType = result.Type
+	result.Name = &place.Name
+	result.Address = nominatimAddress(place)
+	if value, ok := place.Properties["rank"].(float64); ok {
+		rank := int(value)
+		result.Rank = &rank
+	}
+	return result
+}
+
+// nominatimAddress details of the place context and address
+func nominatimAddress(place *mbtiles.Place) map[string]string {
+	address := map[string]string{}
+	for _, item := range place.Context {
+		if item.Class != "" && item.Name != "" {
+			address[item.Class] = item.Name
+		}
+	}
+	if place.Address != nil {
+		delete(address, place.Class)
+		if place.Address.HouseNumber != "" {
+			address["house_number"] = place.Address.HouseNumber
+		}
+		if place.Address.Street != "" {
+			address["road"] = place.Address.Street
+		}
+		if place.Address.Locality != "" {
+			delete(address, "locality")
+			address["city"] = place.Address.Locality
+		}
+	}
+	if len(address) == 0 {
+		return nil
+	}
+	return address
+}
+
+// newNominatimFeature of geojson format
+func newNominatimFeature(place *mbtiles.Place) *geojson.Feature {
+	result := newNominatimPlace(place, true)
+	feature := geojson.NewFeature(place.Geometry)
+	feature.BBox = geojson.NewBBox(place.Bound)
+	feature.Properties = geojson.Properties{
+		"category":     result.Category,
+		"type":         result.Type,
+		"importance":   result.Importance,
+		"addresstype":  result.AddressType,
+		"name":         place.Name,
+		"display_name": result.DisplayName,
+	}
+	if result.FeatureID != nil {
+		feature.Properties["feature_id"] = *result.FeatureID
+	}
+	if result.Rank != nil {
+		feature.Properties["rank"] = *result.Rank
+	}
+	return feature
+}
+
+// featureID of vector tile feature, nil if it's unknown
+func featureID(place *mbtiles.Place) *int64 {
+	if value, ok := place.ID.(float64); ok {
+		id := int64(value)
+		return &id
+	}
+	return nil
+}
+
+// formatCoordinate as Nominatim does
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 7, 64)
+}
+
+// writeError response
+func writeError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+		},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// writeJSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer(newTestManager(t), 10, "*")
+	for name, test := range map[string]struct {
+		method string
+		url    string
+		status int
+		body   string
+	}{
+		"status":          {http.MethodGet, "/status", http.StatusOK, "OK"},
+		"status.php":      {http.MethodGet, "/status.php", http.StatusOK, "OK"},
+		"not found":       {http.MethodGet, "/lookup", http.StatusNotFound, ""},
+		"method":          {http.MethodPost, "/search?q=laguna", http.StatusMethodNotAllowed, ""},
+		"preflight":       {http.MethodOptions, "/search", http.StatusNoContent, ""},
+		"empty query":     {http.MethodGet, "/search?q=", http.StatusBadRequest, ""},
+		"blank query":     {http.MethodGet, "/search?q=+", http.StatusBadRequest, ""},
+		"missing query":   {http.MethodGet, "/search", http.StatusBadRequest, ""},
+		"search format":   {http.MethodGet, "/search?q=laguna&format=xml", http.StatusBadRequest, ""},
+		"search viewbox":  {http.MethodGet, "/search?q=laguna&viewbox=1,2,3", http.StatusBadRequest, ""},
+		"search":          {http.MethodGet, "/search?q=laguna", http.StatusOK, ""},
+		"reverse missing": {http.MethodGet, "/reverse?lat=28.4", http.StatusBadRequest, ""},
+		"reverse range":   {http.MethodGet, "/reverse?lat=100&lon=-16.3", http.StatusBadRequest, ""},
+		"reverse format":  {http.MethodGet, "/reverse?lat=28.4&lon=-16.3&format=xml", http.StatusBadRequest, ""},
+		"reverse":         {http.MethodGet, "/reverse?lat=28.4853&lon=-16.3159", http.StatusOK, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, httptest.NewRequest(test.method, test.url, nil))
+		require.Equal(t, test.status, recorder.Code, name)
+		require.Equal(t, "*", recorder.Header().Get("Access-Control-Allow-Origin"), name)
+		if test.body != "" {
+			require.Equal(t, test.body, recorder.Body.String(), name)
+		}
+	}
+}
+
+func TestServerSearch(t *testing.T) {
+	server := NewServer(newTestManager(t), 10, "")
+
+	var places []map[string]interface{}
+	body := get(t, server, "/search?q=laguna&format=jsonv2", &places)
+	require.Len(t, places, 1, body)
+	place := places[0]
+	require.Equal(t, "San Cristóbal de La Laguna", place["name"])
+	require.Equal(t, "place", place["category"])
+	require.Equal(t, "city", place["type"])
+	require.Equal(t, float64(2), place["feature_id"])
+	require.Equal(t, float64(2), place["rank"])
+	require.Len(t, place["boundingbox"], 4)
+
+	// Nominatim ids aren't guessed from tiles
+	for _, key := range []string{"place_id", "osm_type", "osm_id", "place_rank"} {
+		require.NotContains(t, place, key)
+	}
+
+	// CORS is disabled
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/search?q=laguna", nil))
+	require.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	// json format uses class instead of category
+	places = nil
+	body = get(t, server, "/search?q=santa&limit=1", &places)
+	require.Len(t, places, 1, body)
+	require.Equal(t, "place", places[0]["class"])
+	require.NotContains(t, places[0], "category")
+
+	// Bounded viewbox excludes places outside
+	places = nil
+	body = get(t, server, "/search?q=laguna&viewbox=-16.6,28.40,-16.4,28.45&bounded=1", &places)
+	require.Empty(t, places, body)
+
+	// Nothing is found
+	places = nil
+	body = get(t, server, "/search?q=nowhere", &places)
+	require.Empty(t, places, body)
+
+	// GeoJSON format
+	var fc struct {
+		Type     string `json:"type"`
+		Licence  string `json:"licence"`
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	get(t, server, "/search?q=ursula&format=geojson", &fc)
+	require.Equal(t, "FeatureCollection", fc.Type)
+	require.Equal(t, licence, fc.Licence)
+	require.Len(t, fc.Features, 1)
+	require.Equal(t, "Santa Úrsula", fc.Features[0].Properties["name"])
+	require.Equal(t, float64(3), fc.Features[0].Properties["feature_id"])
+	require.NotContains(t, fc.Features[0].Properties, "osm_id")
+}
+
+func TestServerReverse(t *testing.T) {
+	server := NewServer(newTestManager(t), 10, "")
+
+	var place map[string]interface{}
+	body := get(t, server, "/reverse?lat=28.4260&lon=-16.4890&format=jsonv2", &place)
+	require.Equal(t, "Santa Úrsula", place["name"], body)
+	require.Equal(t, "town", place["addresstype"])
+	require.NotContains(t, place, "osm_type")
+
+	// Nothing is near
+	var result map[string]interface{}
+	body = get(t, server, "/reverse?lat=0&lon=0", &result)
+	require.Equal(t, map[string]interface{}{"error": "Unable to geocode"}, result, body)
+
+	var fc struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	get(t, server, "/reverse?lat=28.4636&lon=-16.2518&format=geojson", &fc)
+	require.Len(t, fc.Features, 1)
+	require.Equal(t, "Santa Cruz de Tenerife", fc.Features[0].Properties["name"])
+}
+
+// get JSON response of successful request into v
+func get(t *testing.T, handler http.Handler, url string, v interface{}) string {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, http.StatusOK, recorder.Code, url)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"), url)
+	body := recorder.Body.String()
+	require.NoError(t, json.Unmarshal([]byte(body), v), body)
+	return body
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// Inspect MBTiles file command
+var command = &cobra.Command{
+	Use:     "mbtiles-info",
+	Long:    "Prints metadata, schema, tile sizes by zoom level, tile formats and the largest tiles of `mbtiles` file",
+	Args:    cobra.NoArgs,
+	Version: "0.0.1",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(nil)
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		if !viper.GetBool("verbose") {
+			logrus.SetLevel(logrus.WarnLevel)
+		}
+
+		manager, err := mbtiles.NewManager(viper.GetString("mbtiles"))
+		if err != nil {
+			logrus.WithError(err).Fatal("Unable to open mbtiles database")
+		}
+		info, err := manager.Info(context.Background(), viper.GetInt("largest"))
+		if err != nil {
+			logrus.WithError(err).Fatal("Unable to inspect mbtiles database")
+		}
+
+		switch format := viper.GetString("format"); format {
+		case "json":
+			data, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				logrus.WithError(err).Fatal("Unable to generate JSON")
+			}
+			fmt.Println(string(data))
+		case "table":
+			if err := writeTable(os.Stdout, info); err != nil {
+				logrus.WithError(err).Fatal("Unable to write table")
+			}
+		default:
+			logrus.WithField("format", format).Fatal("Unknown output format")
+		}
+	},
+}
+
+// writeTable of info sections
+func writeTable(out io.Writer, info *mbtiles.Info) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	if meta := info.Meta; meta != nil {
+		fmt.Fprintln(w, "METADATA")
+		for _, row := range [][2]string{
+			{"name", meta.Name},
+			{"description", meta.Description},
+			{"version", meta.Version},
+			{"format", meta.Format},
+			{"type", meta.Type},
+			{"zoom", fmt.Sprintf("%d-%d", meta.MinZoom, meta.MaxZoom)},
+			{"bounds", floats(meta.Bounds)},
+			{"center", floats(meta.Center)},
+			{"attribution", meta.Attribution},
+		} {
+			if row[1] != "" {
+				fmt.Fprintf(w, "%s\t%s\n", row[0], row[1])
+			}
+		}
+		for _, layer := range meta.VectorLayers {
+			fmt.Fprintf(w, "layer\t%s\t%d-%d\t%d fields\n", layer.ID, layer.MinZoom, layer.MaxZoom, len(layer.Fields))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "SCHEMA\t%s\n\n", info.Schema)
+
+	fmt.Fprintln(w, "ZOOM\tTILES\tBYTES\tMIN\tAVG\tMAX\tP95")
+	for _, zoom := range info.Zooms {
+		writeSizes(w, fmt.Sprint(zoom.Zoom), zoom.TileSizeStats)
+	}
+	writeSizes(w, "all", info.Tiles)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "FORMAT\tTILES")
+	var formats []string
+	for format := range info.Formats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	for _, format := range formats {
+		fmt.Fprintf(w, "%s\t%d\n", format, info.Formats[format])
+	}
+
+	if len(info.Largest) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "LARGEST\tBYTES")
+		for _, tile := range info.Largest {
+			fmt.Fprintf(w, "%d/%d/%d\t%d\n", tile.Z, tile.X, tile.Y, tile.Size)
+		}
+	}
+	return w.Flush()
+}
+
+// writeSizes row
+func writeSizes(w io.Writer, name string, stats mbtiles.TileSizeStats) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.0f\t%d\t%d\n", name, stats.Count, stats.Bytes, stats.Min, stats.Avg, stats.Max, stats.P95)
+}
+
+// floats as comma separated values
+func floats(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Initializing options
+func init() {
+	command.Flags().StringP("mbtiles", "d", "data/tiles-world-vector.mbtiles", "MBtiles data path")
+	command.Flags().StringP("format", "f", "table", "output format: table or json")
+	command.Flags().Int("largest", 10, "number of the largest tiles to list")
+	command.Flags().BoolP("verbose", "v", false, "Output details")
+}
+
+// main command
+func main() {
+	// Bind all flags
+	if err := viper.BindPFlags(command.Flags()); err != nil {
+		logrus.WithError(err).Fatal("Unable to bind command line flags")
+	}
+
+	// Handle environment variables
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	// Read settings from config file
+	viper.AddConfigPath(".")
+	viper.SetConfigName("config")
+
+	// Get YAML
+	if err := viper.ReadInConfig(); err != nil {
+		// Don't fail if config not found
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logrus.WithError(err).Warn("Unable to read config file")
+		}
+	}
+
+	// Pass control
+	if err := command.Execute(); err != nil {
+		logrus.WithError(err).Fatal("Failed to execute command")
+	}
+}
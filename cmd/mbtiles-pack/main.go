@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// Pack tiles command
+var command = &cobra.Command{
+	Use:     "mbtiles-pack",
+	Long:    "Packs {z}/{x}/{y}.{ext} tiles directory into `mbtiles` file",
+	Args:    cobra.NoArgs,
+	Version: "0.0.1",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(nil)
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		if !viper.GetBool("verbose") {
+			logrus.SetLevel(logrus.WarnLevel)
+		}
+
+		// Create packer
+		settings := mbtiles.PackerSettings{
+			Path:      viper.GetString("import"),
+			Compress:  viper.GetBool("compress"),
+			BatchSize: viper.GetInt("batch"),
+		}
+
+		mbtilesPath := viper.GetString("export")
+		packer, err := mbtiles.NewPacker(mbtilesPath, settings)
+		if err != nil {
+			logrus.WithError(err).Fatal("Pack tiles")
+		}
+
+		logrus.WithField("export", mbtilesPath).Infof("Start pack")
+		if err = packer.Pack(); err != nil {
+			logrus.WithError(err).Error("Pack tiles")
+		}
+		if err = packer.Close(); err != nil {
+			logrus.WithError(err).Error("Close mbtiles file")
+		}
+		logrus.WithField("export", mbtilesPath).Infof("End pack")
+	},
+}
+
+// Initializing options
+func init() {
+	command.Flags().StringP("import", "i", "tiles", "Import tiles path")
+	command.Flags().StringP("export", "o", "tiles.mbtiles", "Export mbtiles file path")
+	command.Flags().BoolP("compress", "c", true, "Compress raw PBF files")
+	command.Flags().Int("batch", mbtiles.DefaultBatchSize, "Number of tiles inserted in one transaction")
+	command.Flags().BoolP("verbose", "v", false, "Output details")
+}
+
+// main command
+func main() {
+	// Bind all flags
+	if err := viper.BindPFlags(command.Flags()); err != nil {
+		logrus.WithError(err).Fatal("Unable to bind command line flags")
+	}
+
+	// Handle environment variables
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	// Read settings from config file
+	viper.AddConfigPath(".")
+	viper.SetConfigName("config")
+
+	// Get YAML
+	if err := viper.ReadInConfig(); err != nil {
+		// Don't fail if config not found
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logrus.WithError(err).Warn("Unable to read config file")
+		}
+	}
+
+	// Pass control
+	if err := command.Execute(); err != nil {
+		logrus.WithError(err).Fatal("Failed to execute command")
+	}
+}
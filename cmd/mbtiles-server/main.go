@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Serve tiles command
+var command = &cobra.Command{
+	Use:     "mbtiles-server",
+	Long:    "Serves tiles and TileJSON from `mbtiles` files",
+	Args:    cobra.NoArgs,
+	Version: "0.0.1",
+	Run: func(cmd *cobra.Command, args []string) {
+		log.SetOutput(nil)
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		if !viper.GetBool("verbose") {
+			logrus.SetLevel(logrus.WarnLevel)
+		}
+
+		server, err := NewServer(viper.GetStringSlice("mbtiles"), viper.GetString("url"), viper.GetString("cors"))
+		if err != nil {
+			logrus.WithError(err).Fatal("Unable to open mbtiles database")
+		}
+
+		listen := viper.GetString("listen")
+		logrus.WithField("listen", listen).Info("Start server")
+		if err := http.ListenAndServe(listen, server); err != nil {
+			logrus.WithError(err).Fatal("Serve tiles")
+		}
+	},
+}
+
+// Initializing options
+func init() {
+	command.Flags().StringSliceP("mbtiles", "d", []string{"data/tiles-world-vector.mbtiles"}, "MBtiles data paths, served by file names")
+	command.Flags().StringP("listen", "l", ":8080", "HTTP listen address")
+	command.Flags().StringP("url", "u", "", "public base URL of tiles, derived from request by default")
+	command.Flags().String("cors", "*", "Access-Control-Allow-Origin header value, empty to disable")
+	command.Flags().BoolP("verbose", "v", false, "Output details")
+}
+
+// main command
+func main() {
+	// Bind all flags
+	if err := viper.BindPFlags(command.Flags()); err != nil {
+		logrus.WithError(err).Fatal("Unable to bind command line flags")
+	}
+
+	// Handle environment variables
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
+	viper.AutomaticEnv()
+
+	// Read settings from config file
+	viper.AddConfigPath(".")
+	viper.SetConfigName("config")
+
+	// Get YAML
+	if err := viper.ReadInConfig(); err != nil {
+		// Don't fail if config not found
+		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
+			logrus.WithError(err).Warn("Unable to read config file")
+		}
+	}
+
+	// Pass control
+	if err := command.Execute(); err != nil {
+		logrus.WithError(err).Fatal("Failed to execute command")
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// tilePathPattern matches /{tileset}/{z}/{x}/{y}.{ext}
+var tilePathPattern = regexp.MustCompile(`^/([^/]+)/(\d+)/(\d+)/(\d+)\.([a-z0-9]+)$`)
+
+// tileJSONPathPattern matches /{tileset}.json
+var tileJSONPathPattern = regexp.MustCompile(`^/([^/]+)\.json$`)
+
+// Server of tiles from MBTiles files
+type Server struct {
+	tilesets map[string]*mbtiles.Manager
+
+	// Public base URL used in TileJSON, request host by default
+	baseUrl string
+
+	// Value of Access-Control-Allow-Origin header, disabled if empty
+	cors string
+}
+
+// NewServer opens MBTiles files, each of them is served as a tileset named by file name
+func NewServer(paths []string, baseUrl string, cors string) (*Server, error) {
+	s := &Server{
+		tilesets: map[string]*mbtiles.Manager{},
+		baseUrl:  strings.TrimSuffix(baseUrl, "/"),
+		cors:     cors,
+	}
+	for _, path := range paths {
+		// SQLite creates an empty database of missing file
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if _, exists := s.tilesets[name]; exists {
+			return nil, fmt.Errorf("duplicate tileset name %q of %s", name, path)
+		}
+		manager, err := mbtiles.NewManager(path)
+		if err != nil {
+			return nil, err
+		}
+		s.tilesets[name] = manager
+		logrus.
+			WithField("tileset", name).
+			WithField("path", path).
+			Info("Serve tileset")
+	}
+	return s, nil
+}
+
+// ServeHTTP routes requests to tiles, TileJSON or tilesets list
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cors != "" {
+		w.Header().Set("Access-Control-Allow-Origin", s.cors)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Content-Encoding")
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == "/" {
+		s.serveIndex(w, r)
+		return
+	}
+	if match := tileJSONPathPattern.FindStringSubmatch(r.URL.Path); match != nil {
+		s.serveTileJSON(w, r, match[1])
+		return
+	}
+	if match := tilePathPattern.FindStringSubmatch(r.URL.Path); match != nil {
+		z, _ := strconv.ParseInt(match[2], 10, 64)
+		x, _ := strconv.ParseInt(match[3], 10, 64)
+		y, _ := strconv.ParseInt(match[4], 10, 64)
+		s.serveTile(w, r, match[1], z, x, y)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// serveIndex lists tilesets with TileJSON URLs
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for name := range s.tilesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	index := map[string]string{}
+	for _, name := range names {
+		index[name] = fmt.Sprintf("%s/%s.json", s.getBaseUrl(r), name)
+	}
+	writeJSON(w, index)
+}
+
+// serveTileJSON built from the tileset metadata
+func (s *Server) serveTileJSON(w http.ResponseWriter, r *http.Request, name string) {
+	manager, ok := s.tilesets[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	meta, err := manager.GetMeta()
+	if err != nil {
+		logrus.
+			WithField("tileset", name).
+			WithError(err).
+			Error("Get meta")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, meta.TileJSON(fmt.Sprintf("%s/%s", s.getBaseUrl(r), name)))
+}
+
+// serveTile data, y is in XYZ scheme
+func (s *Server) serveTile(w http.ResponseWriter, r *http.Request, name string, z, x, y int64) {
+	manager, ok := s.tilesets[name]
+	if !ok || z > 30 || y >= int64(1)<<uint(z) {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := manager.GetTile(z, x, mbtiles.FlipRow(z, y))
+	if err != nil {
+		logrus.
+			WithField("tileset", name).
+			WithError(err).
+			Error("Get tile data")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if len(data) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// ETag of stored data, suffixed by encoding of compressed response
+	hash := fnv.New64a()
+	_, _ = hash.Write(data)
+	etag := fmt.Sprintf("%x", hash.Sum64())
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	info, _ := mbtiles.DetectTile(data)
+	w.Header().Set("Content-Type", info.MimeType())
+	if encoding := info.ContentEncoding(); encoding != "" {
+		switch {
+		case acceptsEncoding(r, encoding):
+			w.Header().Set("Content-Encoding", encoding)
+			etag += "-" + encoding
+		case info.Encoding == mbtiles.GZIP || info.Encoding == mbtiles.ZLIB:
+			if data, err = info.Decode(data); err != nil {
+				logrus.WithField("tileset", name).WithError(err).Error("Decompress tile")
+			}
+		default:
+			// Content of not decodable encodings can't be served to the client
+			logrus.
+				WithField("tileset", name).
+				WithField("encoding", encoding).
+				Warn("Tile encoding isn't accepted by client")
+			http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+			return
+		}
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// getBaseUrl configured or derived from request
+func (s *Server) getBaseUrl(r *http.Request) string {
+	if s.baseUrl != "" {
+		return s.baseUrl
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+// acceptsEncoding checks Accept-Encoding request header
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		accepted = strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		if accepted == encoding || accepted == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eslider/geo-tools/pkg/mbtiles"
+)
+
+// newTestServer of test tileset with gzipped tile 1/0/0 and its uncompressed data, tile 1/1/0 is zstd compressed
+func newTestServer(t *testing.T, baseUrl string, cors string) (*Server, []byte) {
+	fc := geojson.NewFeatureCollection()
+	fc.Append(geojson.NewFeature(orb.Point{-16.25, 28.46}))
+	layers := mvt.NewLayers(map[string]*geojson.FeatureCollection{"place": fc})
+	layers.ProjectToTile(maptile.New(0, 0, 1))
+	data, err := mvt.Marshal(layers)
+	require.NoError(t, err)
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	writer, err := mbtiles.NewWriter(path, mbtiles.WriterSettings{})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteTile(1, 0, mbtiles.FlipRow(1, 0), compressed.Bytes()))
+	require.NoError(t, writer.WriteTile(1, 1, mbtiles.FlipRow(1, 0), []byte("\x28\xb5\x2f\xfd\x00\x00")))
+	require.NoError(t, writer.WriteMeta(&mbtiles.Meta{Name: "test", Format: "pbf", MaxZoom: 1}))
+	require.NoError(t, writer.Close())
+
+	server, err := NewServer([]string{path}, baseUrl, cors)
+	require.NoError(t, err)
+	return server, data
+}
+
+// serve request with headers
+func serve(server *Server, method string, url string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, nil)
+	for key, value := range headers {
+		r.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	return w
+}
+
+func TestServeTile(t *testing.T) {
+	server, testTile := newTestServer(t, "", "")
+
+	// Gzip is accepted
+	w := serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"Accept-Encoding": "gzip, deflate"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	require.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	require.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	var data bytes.Buffer
+	_, err = data.ReadFrom(reader)
+	require.NoError(t, err)
+	require.Equal(t, testTile, data.Bytes())
+	etag := w.Header().Get("ETag")
+	require.Regexp(t, `^"[0-9a-f]+-gzip"$`, etag)
+
+	// Gzip isn't accepted, the tile is decompressed with ETag of other representation
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("Content-Encoding"))
+	require.Equal(t, testTile, w.Body.Bytes())
+	identityETag := w.Header().Get("ETag")
+	require.Equal(t, strings.TrimSuffix(etag, `-gzip"`)+`"`, identityETag)
+
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"Accept-Encoding": "br;q=1.0, *;q=0.5"})
+	require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	// Zstandard can't be decoded for clients not accepting it
+	w = serve(server, http.MethodGet, "/test/1/1/0.pbf", map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+	w = serve(server, http.MethodGet, "/test/1/1/0.pbf", map[string]string{"Accept-Encoding": "gzip, zstd"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "zstd", w.Header().Get("Content-Encoding"))
+
+	// Not modified
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"If-None-Match": etag, "Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Empty(t, w.Body.Bytes())
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"If-None-Match": identityETag})
+	require.Equal(t, http.StatusNotModified, w.Code)
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, testTile, w.Body.Bytes())
+	w = serve(server, http.MethodGet, "/test/1/0/0.pbf", map[string]string{"If-None-Match": `"other"`})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// HEAD has no body
+	w = serve(server, http.MethodHead, "/test/1/0/0.pbf", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Body.Bytes())
+
+	// Not found
+	for _, url := range []string{
+		"/test/1/1/1.pbf",
+		"/test/1/0/2.pbf",
+		"/test/31/0/0.pbf",
+		"/other/1/0/0.pbf",
+		"/test/1/0.pbf",
+		"/other.json",
+	} {
+		require.Equal(t, http.StatusNotFound, serve(server, http.MethodGet, url, nil).Code, url)
+	}
+
+	require.Equal(t, http.StatusMethodNotAllowed, serve(server, http.MethodPost, "/test/1/0/0.pbf", nil).Code)
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := NewServer([]string{filepath.Join(t.TempDir(), "missing.mbtiles")}, "", "")
+	require.Error(t, err)
+}
+
+func TestServeTileJSON(t *testing.T) {
+	var tileJSON mbtiles.TileJSON
+
+	// Base URL of request
+	server, _ := newTestServer(t, "", "")
+	w := serve(server, http.MethodGet, "/test.json", map[string]string{"X-Forwarded-Proto": "https"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tileJSON))
+	require.Equal(t, "3.0.0", tileJSON.TileJSON)
+	require.Equal(t, []string{"https://example.com/test/{z}/{x}/{y}.pbf"}, tileJSON.Tiles)
+
+	// Configured base URL
+	server, _ = newTestServer(t, "http://tiles.local/", "")
+	w = serve(server, http.MethodGet, "/test.json", nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tileJSON))
+	require.Equal(t, []string{"http://tiles.local/test/{z}/{x}/{y}.pbf"}, tileJSON.Tiles)
+
+	// Index of tilesets
+	var index map[string]string
+	w = serve(server, http.MethodGet, "/", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &index))
+	require.Equal(t, map[string]string{"test": "http://tiles.local/test.json"}, index)
+}
+
+func TestServeCORS(t *testing.T) {
+	server, _ := newTestServer(t, "", "https://map.local")
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := serve(server, method, "/test/1/0/0.pbf", nil)
+		require.Equal(t, "https://map.local", w.Header().Get("Access-Control-Allow-Origin"), method)
+		require.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"), method)
+		require.Equal(t, "ETag, Content-Encoding", w.Header().Get("Access-Control-Expose-Headers"), method)
+	}
+	require.Equal(t, http.StatusNoContent, serve(server, http.MethodOptions, "/test.json", nil).Code)
+
+	// Disabled
+	server, _ = newTestServer(t, "", "")
+	w := serve(server, http.MethodGet, "/test/1/0/0.pbf", nil)
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
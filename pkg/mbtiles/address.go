@@ -0,0 +1,372 @@
+package mbtiles
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/orb/planar"
+	"github.com/paulmach/orb/project"
+)
+
+// Address of a found place
+type Address struct {
+	HouseNumber string `json:"housenumber,omitempty"`
+	Street      string `json:"street,omitempty"`
+	Locality    string `json:"locality,omitempty"`
+}
+
+// AddressOptions groups all options for Manager.SearchAddress
+type AddressOptions struct {
+	// Maximal results number, 5 by default
+	Limit int
+
+	// Zoom level of tiles to look up, search zoom by default
+	Zoom int
+
+	// Layer of street names, "transportation_name" by default
+	StreetLayer string
+
+	// Layer of house numbers, "housenumber" by default
+	HouseNumberLayer string
+
+	// Layer of localities, "place" by default
+	PlaceLayer string
+
+	// Focus point, nearby addresses are boosted and get distance in meters
+	Focus *orb.Point
+
+	// Bound restricting addresses, tiles outside of it aren't decoded
+	Bound *orb.Bound
+}
+
+// Address search distances in meters
+const (
+	// houseNumberDistance from the street
+	houseNumberDistance = 50.0
+
+	// localityDistance of streets from the locality center
+	localityDistance = 15000.0
+)
+
+// Address score weights of street match, house number and locality match
+const (
+	streetWeight      = 0.6
+	houseNumberWeight = 0.3
+	localityWeight    = 0.1
+)
+
+// houseNumberPattern of a query word, e.g. "12" or "12a"
+var houseNumberPattern = regexp.MustCompile(`^\d+[[:alpha:]]?$`)
+
+// ParseAddress query like "Calle Castillo 12, Santa Cruz".
+// The house number is the last number of the street part, if it's the first or the last word,
+// so that "Calle 25 de Julio 3" is number 3 and "Calle 25 de Julio" has no number.
+// Returns false if the query has no house number.
+func ParseAddress(query string) (Address, bool) {
+	var address Address
+	parts := strings.SplitN(query, ",", 2)
+	if len(parts) == 2 {
+		address.Locality = strings.TrimSpace(parts[1])
+	}
+	words := strings.Fields(parts[0])
+	number := -1
+	for i, word := range words {
+		if houseNumberPattern.MatchString(word) {
+			number = i
+		}
+	}
+	if number > 0 && number < len(words)-1 {
+		number = -1
+	}
+	if number >= 0 {
+		address.HouseNumber = words[number]
+		words = append(append([]string{}, words[:number]...), words[number+1:]...)
+	}
+	address.Street = strings.Join(words, " ")
+	return address, address.HouseNumber != "" && address.Street != ""
+}
+
+// addressStreet matched by the query
+type addressStreet struct {
+	name  string
+	score float64
+
+	// Geometry in Web Mercator meters
+	mercator orb.Geometry
+}
+
+// visitedLayer of tile
+type visitedLayer struct {
+	tile  maptile.Tile
+	layer string
+}
+
+// addressLocality context of the address
+type addressLocality struct {
+	name   string
+	center orb.Point
+	score  float64
+}
+
+// SearchAddress of house numbers on streets matching the query, ordered by relevance.
+// Streets are found without house numbers if they aren't in tiles.
+func (m *Manager) SearchAddress(query string, opts AddressOptions) (Places, error) {
+	cfg := m.SearchConfig()
+	if opts.Limit < 1 {
+		opts.Limit = 5
+	}
+	if opts.Zoom < 1 {
+		zoom, err := m.searchZoom(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.Zoom = zoom
+	}
+	if opts.StreetLayer == "" {
+		opts.StreetLayer = "transportation_name"
+	}
+	if opts.HouseNumberLayer == "" {
+		opts.HouseNumberLayer = "housenumber"
+	}
+	if opts.PlaceLayer == "" {
+		opts.PlaceLayer = "place"
+	}
+
+	address, _ := ParseAddress(query)
+	if address.Street == "" {
+		return nil, nil
+	}
+	streetMatcher, err := newNameMatcher(address.Street, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Streets are looked up around each of the localities, everywhere if the locality isn't found
+	filter := NewZoomFilter(opts.Zoom)
+	filter.Bound = opts.Bound
+	filters := []TileFilter{filter}
+	var localityMatcher nameMatcher
+	if address.Locality != "" {
+		localities, err := m.Search(address.Locality, 5)
+		if err != nil {
+			return nil, err
+		}
+		if len(localities) > 0 {
+			if localityMatcher, err = newNameMatcher(address.Locality, cfg); err != nil {
+				return nil, err
+			}
+			filters = filters[:0]
+			for _, locality := range localities {
+				bound := padBound(locality.Bound, localityDistance)
+				if opts.Bound != nil {
+					var ok bool
+					if bound, ok = intersectBound(bound, *opts.Bound); !ok {
+						continue
+					}
+				}
+				filter := NewZoomFilter(opts.Zoom)
+				filter.Bound = &bound
+				filters = append(filters, filter)
+			}
+		}
+	}
+
+	nameKeys := cfg.matchKeys()
+	var streets []*addressStreet
+	var localities []*addressLocality
+	var houses Places
+	found := placeSet{}
+	visited := map[visitedLayer]bool{}
+	walk := func(tile *Tile, layer *mvt.Layer) bool {
+		if layer.Name != opts.StreetLayer && layer.Name != opts.HouseNumberLayer && layer.Name != opts.PlaceLayer {
+			return true
+		}
+
+		// Tiles of overlapping localities are read once
+		mapTile := tile.MapTile()
+		key := visitedLayer{mapTile, layer.Name}
+		if visited[key] {
+			return true
+		}
+		visited[key] = true
+		layer.ProjectToWGS84(mapTile)
+		for _, feature := range layer.Features {
+			switch layer.Name {
+			case opts.StreetLayer:
+				score, ok := matchNames(streetMatcher, placeNames(feature.Properties, nameKeys))
+				if !ok {
+					continue
+				}
+				streets = append(streets, &addressStreet{
+					name:     firstStringProperty(feature.Properties, cfg.labelKeys()),
+					score:    score,
+					mercator: project.Geometry(orb.Clone(feature.Geometry), project.WGS84.ToMercator),
+				})
+			case opts.HouseNumberLayer:
+				if !strings.EqualFold(feature.Properties.MustString("housenumber", ""), address.HouseNumber) {
+					continue
+				}
+				house := cfg.newPlace(feature, layer.Name, mapTile)
+
+				// Tiles may cover more than the bound
+				if opts.Bound != nil && !opts.Bound.Contains(house.Center) {
+					continue
+				}
+				if _, ok := found.add(house); ok {
+					houses = append(houses, house)
+				}
+			case opts.PlaceLayer:
+				name := firstStringProperty(feature.Properties, cfg.labelKeys())
+				if name == "" {
+					continue
+				}
+				locality := &addressLocality{name: name, center: feature.Geometry.Bound().Center()}
+				if localityMatcher != nil {
+					locality.score, _ = matchNames(localityMatcher, placeNames(feature.Properties, nameKeys))
+				}
+				localities = append(localities, locality)
+			}
+		}
+		return true
+	}
+	for _, filter := range filters {
+		if err := m.WalkTileLayers(context.Background(), filter, walk); err != nil {
+			return nil, err
+		}
+	}
+
+	// House numbers next to the matched streets
+	ranked := &rankedPlaces{limit: opts.Limit}
+	for _, house := range houses {
+		street := nearestStreet(streets, house.Center)
+		if street == nil {
+			continue
+		}
+		house.Address = &Address{HouseNumber: house.Properties.MustString("housenumber", ""), Street: street.name}
+		house.Name = street.name + " " + house.Address.HouseNumber
+		house.Class = "housenumber"
+		house.Score = streetWeight*street.score + houseNumberWeight
+		if !setLocality(house, localities, localityMatcher != nil) {
+			continue
+		}
+		setFocus(house, opts.Focus)
+		ranked.add(house)
+	}
+	if len(ranked.places) > 0 {
+		return m.withContext(ranked.result(), nil)
+	}
+
+	// Streets without the house number
+	var places Places
+	streetPlaces := map[string]*Place{}
+	for _, street := range streets {
+		geometry := project.Geometry(orb.Clone(street.mercator), project.Mercator.ToWGS84)
+		bound := geometry.Bound()
+		if place, ok := streetPlaces[street.name]; ok && geo.Distance(place.Center, bound.Center()) < localityDistance {
+			place.Bound = place.Bound.Union(bound)
+			place.Center = place.Bound.Center()
+			continue
+		}
+		place := &Place{
+			Name:       street.name,
+			Class:      "street",
+			Center:     bound.Center(),
+			Bound:      bound,
+			Layer:      opts.StreetLayer,
+			Geometry:   geometry,
+			Properties: geojson.Properties{"name": street.name},
+			Address:    &Address{Street: street.name},
+			Score:      streetWeight * street.score,
+		}
+		if !setLocality(place, localities, localityMatcher != nil) {
+			continue
+		}
+		streetPlaces[street.name] = place
+		places = append(places, place)
+	}
+
+	// Streets are merged before their centers are restricted and focused
+	for _, place := range places {
+		if opts.Bound != nil && !opts.Bound.Contains(place.Center) {
+			continue
+		}
+		setFocus(place, opts.Focus)
+		ranked.add(place)
+	}
+	return m.withContext(ranked.result(), nil)
+}
+
+// setFocus distance and boosted score of the place unless focus is nil
+func setFocus(place *Place, focus *orb.Point) {
+	if focus == nil {
+		return
+	}
+	distance := geo.Distance(*focus, place.Center)
+	place.Distance = &distance
+	place.Score = focusScore(place.Score, distance)
+}
+
+// intersectBound of both bounds, false if they don't intersect
+func intersectBound(a orb.Bound, b orb.Bound) (orb.Bound, bool) {
+	if !a.Intersects(b) {
+		return orb.Bound{}, false
+	}
+	return orb.Bound{
+		Min: orb.Point{math.Max(a.Min.Lon(), b.Min.Lon()), math.Max(a.Min.Lat(), b.Min.Lat())},
+		Max: orb.Point{math.Min(a.Max.Lon(), b.Max.Lon()), math.Min(a.Max.Lat(), b.Max.Lat())},
+	}, true
+}
+
+// nearestStreet to the point within houseNumberDistance, nil if there is no such street
+func nearestStreet(streets []*addressStreet, point orb.Point) *addressStreet {
+	mercator := project.Point(point, project.WGS84.ToMercator)
+
+	// Web Mercator meters are stretched by latitude
+	scale := math.Cos(point.Lat() * math.Pi / 180)
+	var nearest *addressStreet
+	nearestDistance := houseNumberDistance
+	for _, street := range streets {
+		if distance := planar.DistanceFrom(street.mercator, mercator) * scale; distance <= nearestDistance {
+			nearest, nearestDistance = street, distance
+		}
+	}
+	return nearest
+}
+
+// setLocality of the nearest place, matched localities are preferred if they're required.
+// Returns false if the required locality isn't near.
+func setLocality(place *Place, localities []*addressLocality, required bool) bool {
+	var nearest *addressLocality
+	nearestDistance := math.Inf(1)
+	for _, locality := range localities {
+		if required && locality.score == 0 {
+			continue
+		}
+		if distance := geo.Distance(place.Center, locality.center); distance < nearestDistance {
+			nearest, nearestDistance = locality, distance
+		}
+	}
+	if nearest == nil || nearestDistance > localityDistance {
+		return !required
+	}
+	place.Address.Locality = nearest.name
+	place.Score += localityWeight * nearest.score
+	return true
+}
+
+// padBound by distance in meters
+func padBound(bound orb.Bound, distance float64) orb.Bound {
+	lat := distance / 111320
+	lon := lat / math.Max(math.Cos(bound.Center().Lat()*math.Pi/180), 0.01)
+	return orb.Bound{
+		Min: orb.Point{bound.Min.Lon() - lon, bound.Min.Lat() - lat},
+		Max: orb.Point{bound.Max.Lon() + lon, bound.Max.Lat() + lat},
+	}
+}
@@ -0,0 +1,103 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+// testAddressFeatures of a street in Santa Cruz de Tenerife
+var testAddressFeatures = append([]testFeature{
+	{layer: "transportation_name", zoom: 14, geometry: orb.LineString{{-16.2520, 28.4690}, {-16.2490, 28.4680}}, properties: geojson.Properties{
+		"name": "Calle del Castillo", "name:latin": "Calle del Castillo", "class": "tertiary",
+	}},
+	{layer: "housenumber", zoom: 14, geometry: orb.Point{-16.2505, 28.4686}, properties: geojson.Properties{
+		"housenumber": "12",
+	}},
+	{layer: "housenumber", zoom: 14, geometry: orb.Point{-16.2500, 28.4682}, properties: geojson.Properties{
+		"housenumber": "14",
+	}},
+	// Same number far from the street
+	{layer: "housenumber", zoom: 14, geometry: orb.Point{-16.3150, 28.4850}, properties: geojson.Properties{
+		"housenumber": "12",
+	}},
+}, testPlaceFeatures...)
+
+func TestParseAddress(t *testing.T) {
+	for query, expected := range map[string]Address{
+		"Calle Castillo 12, Santa Cruz": {HouseNumber: "12", Street: "Calle Castillo", Locality: "Santa Cruz"},
+		"12a Calle Castillo":            {HouseNumber: "12a", Street: "Calle Castillo"},
+		"Calle 25 de Julio 3":           {HouseNumber: "3", Street: "Calle 25 de Julio"},
+		"Route 66 12":                   {HouseNumber: "12", Street: "Route 66"},
+		"5 Calle Mayor, La Orotava":     {HouseNumber: "5", Street: "Calle Mayor", Locality: "La Orotava"},
+	} {
+		address, ok := ParseAddress(query)
+		require.True(t, ok, query)
+		require.Equal(t, expected, address, query)
+	}
+	for _, query := range []string{"Santa Cruz", "Calle 25 de Julio", "5 Calle 25 de Julio", "Calle 25 de Julio, 3"} {
+		_, ok := ParseAddress(query)
+		require.False(t, ok, query)
+	}
+}
+
+func TestSearchAddress(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testAddressFeatures))
+	require.NoError(t, err)
+
+	for _, query := range []string{"Calle Castillo 12, Santa Cruz", "calle castillo 12"} {
+		places, err := m.SearchAddress(query, AddressOptions{})
+		require.NoError(t, err)
+		require.Len(t, places, 1, query)
+		require.Equal(t, "Calle del Castillo 12", places[0].Name)
+		require.Equal(t, "housenumber", places[0].Layer)
+		require.Equal(t, &Address{HouseNumber: "12", Street: "Calle del Castillo", Locality: "Santa Cruz de Tenerife"}, places[0].Address)
+		require.InDelta(t, -16.2505, places[0].Center.Lon(), 0.0001)
+	}
+
+	// Street is found if house number isn't
+	places, err := m.SearchAddress("Calle Castillo 99", AddressOptions{})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "street", places[0].Class)
+	require.Equal(t, "Calle del Castillo", places[0].Address.Street)
+
+	// Streets of other localities aren't found
+	places, err = m.SearchAddress("Calle Castillo 12, Las Palmas", AddressOptions{})
+	require.NoError(t, err)
+	require.Empty(t, places)
+
+	// Streets are found anywhere if the locality isn't
+	places, err = m.SearchAddress("Calle Castillo 12, Atlantis", AddressOptions{})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Calle del Castillo 12", places[0].Name)
+
+	// Each of matched localities is searched
+	places, err = m.SearchAddress("Calle Castillo 12, Santa", AddressOptions{})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Santa Cruz de Tenerife", places[0].Address.Locality)
+
+	// Addresses are restricted by the bound and get distance to the focus
+	bound := orb.Bound{Min: orb.Point{-16.26, 28.46}, Max: orb.Point{-16.24, 28.48}}
+	focus := orb.Point{-16.2518, 28.4636}
+	places, err = m.SearchAddress("Calle Castillo 12", AddressOptions{Bound: &bound, Focus: &focus})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.True(t, bound.Contains(places[0].Center))
+	require.NotNil(t, places[0].Distance)
+	places, err = m.SearchAddress("Calle Castillo 99", AddressOptions{Bound: &bound})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.True(t, bound.Contains(places[0].Center))
+
+	outside := orb.Bound{Min: orb.Point{-15.5, 28.0}, Max: orb.Point{-15.3, 28.2}}
+	for _, query := range []string{"Calle Castillo 12", "Calle Castillo 12, Santa Cruz", "Calle Castillo 99"} {
+		places, err = m.SearchAddress(query, AddressOptions{Bound: &outside})
+		require.NoError(t, err)
+		require.Empty(t, places, query)
+	}
+}
@@ -0,0 +1,243 @@
+package mbtiles
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geo"
+	"github.com/sirupsen/logrus"
+)
+
+// Properties of GeoJSON feature with administrative context
+const (
+	ContextProperty     = "context"
+	DisplayNameProperty = "display_name"
+)
+
+// ContextClasses of administrative units from specific to general with maximal distance in meters
+// to their place points, polygons must contain the place
+var ContextClasses = []struct {
+	Class       string
+	MaxDistance float64
+}{
+	{"municipality", 15000},
+	{"island", 60000},
+	{"county", 100000},
+	{"province", 250000},
+	{"state", 500000},
+	{"country", 2500000},
+}
+
+// adminLevelClasses of OpenStreetMap admin_level property
+var adminLevelClasses = map[int]string{
+	2: "country",
+	3: "state",
+	4: "state",
+	5: "province",
+	6: "province",
+	7: "municipality",
+	8: "municipality",
+}
+
+// ContextItem of place context chain
+type ContextItem struct {
+	Name  string `json:"name"`
+	Class string `json:"class"`
+}
+
+// contextUnit of administrative hierarchy
+type contextUnit struct {
+	ContextItem
+	center orb.Point
+
+	// Polygon containing places, nil for place points
+	polygon orb.Geometry
+
+	// Country boundary line with country names on its left and right sides, nil for other units
+	line        orb.Geometry
+	left, right string
+}
+
+// contextIndex of administrative units loaded by the first search
+type contextIndex struct {
+	// Config of units layers
+	cfg SearchConfig
+
+	once  sync.Once
+	units map[string][]*contextUnit
+	err   error
+}
+
+// load units of polygon layers and place points once
+func (idx *contextIndex) load(m *Manager) error {
+	idx.once.Do(func() {
+		idx.units = map[string][]*contextUnit{}
+		cfg := idx.cfg
+		zoom, err := m.searchZoom(cfg)
+		if err != nil {
+			idx.err = err
+			return
+		}
+		placeLayers := map[string]bool{}
+		for _, name := range cfg.Layers {
+			placeLayers[name] = true
+		}
+		polygonLayers := map[string]bool{}
+		for _, name := range cfg.ContextLayers {
+			polygonLayers[name] = true
+		}
+		classes := map[string]bool{}
+		for _, c := range ContextClasses {
+			classes[c.Class] = true
+		}
+
+		found := placeSet{}
+		idx.err = m.WalkTileLayers(context.Background(), NewZoomFilter(zoom), func(tile *Tile, layer *mvt.Layer) bool {
+			if !placeLayers[layer.Name] && !polygonLayers[layer.Name] {
+				return true
+			}
+			mapTile := tile.MapTile()
+			layer.ProjectToWGS84(mapTile)
+			for _, feature := range layer.Features {
+				place := cfg.newPlace(feature, layer.Name, mapTile)
+				unit := &contextUnit{ContextItem: ContextItem{Name: place.Name, Class: place.Class}, center: place.Center}
+				level, hasLevel := feature.Properties["admin_level"].(float64)
+				switch feature.Geometry.(type) {
+				case orb.Polygon, orb.MultiPolygon:
+					if place.Name == "" || !polygonLayers[layer.Name] || !hasLevel || adminLevelClasses[int(level)] == "" {
+						continue
+					}
+					unit.Class = adminLevelClasses[int(level)]
+					unit.polygon = feature.Geometry
+				case orb.LineString, orb.MultiLineString:
+					// OpenMapTiles boundaries are lines with country names of both sides
+					if !polygonLayers[layer.Name] || !hasLevel || int(level) != 2 {
+						continue
+					}
+					unit.Class = "country"
+					unit.line = feature.Geometry
+					unit.left = feature.Properties.MustString("adm0_l", "")
+					unit.right = feature.Properties.MustString("adm0_r", "")
+					if unit.left == "" && unit.right == "" {
+						continue
+					}
+				case orb.Point:
+					if place.Name == "" || !placeLayers[layer.Name] || !classes[place.Class] {
+						continue
+					}
+					if _, ok := found.add(place); !ok {
+						continue
+					}
+				default:
+					continue
+				}
+				idx.units[unit.Class] = append(idx.units[unit.Class], unit)
+			}
+			return true
+		})
+	})
+	return idx.err
+}
+
+// chain of units containing or nearest to the place from specific to general
+func (idx *contextIndex) chain(place *Place) []ContextItem {
+	chain := []ContextItem{{Name: place.Name, Class: place.Class}}
+	if place.Address != nil && place.Address.Locality != "" {
+		chain = append(chain, ContextItem{Name: place.Address.Locality, Class: "locality"})
+	}
+	for _, c := range ContextClasses {
+		var best *ContextItem
+		bestDistance := c.MaxDistance
+		for _, unit := range idx.units[c.Class] {
+			if item, distance, ok := unit.locate(place.Center); ok && distance < bestDistance {
+				best, bestDistance = &item, distance
+			}
+		}
+		if best == nil || best.Name == chain[len(chain)-1].Name || (best.Name == place.Name && best.Class == place.Class) {
+			continue
+		}
+		chain = append(chain, *best)
+	}
+	return chain
+}
+
+// locate the point by the unit, returns distance in meters to it
+// or false if the polygon doesn't contain the point
+func (unit *contextUnit) locate(point orb.Point) (ContextItem, float64, bool) {
+	switch {
+	case unit.polygon != nil:
+		return unit.ContextItem, 0, polygonContains(unit.polygon, point)
+	case unit.line != nil:
+		distance, left, ok := lineSide(unit.line, point)
+		item := ContextItem{Name: unit.right, Class: unit.Class}
+		if left {
+			item.Name = unit.left
+		}
+		return item, distance, ok && item.Name != ""
+	}
+	return unit.ContextItem, geo.Distance(unit.center, point), true
+}
+
+// lineSide of the point by the nearest segment of the line.
+// Returns distance in meters to the segment and true for the left side.
+func lineSide(geometry orb.Geometry, point orb.Point) (float64, bool, bool) {
+	var lines []orb.LineString
+	switch g := geometry.(type) {
+	case orb.LineString:
+		lines = []orb.LineString{g}
+	case orb.MultiLineString:
+		lines = g
+	}
+
+	// Degrees of longitude are shorter away from equator
+	scale := math.Cos(point.Lat() * math.Pi / 180)
+	found, left := false, false
+	nearestDistance := math.Inf(1)
+	for _, line := range lines {
+		for i := 1; i < len(line); i++ {
+			a, b := line[i-1], line[i]
+			dx, dy := (b.Lon()-a.Lon())*scale, b.Lat()-a.Lat()
+			px, py := (point.Lon()-a.Lon())*scale, point.Lat()-a.Lat()
+			t := 0.0
+			if length := dx*dx + dy*dy; length > 0 {
+				t = math.Max(0, math.Min(1, (px*dx+py*dy)/length))
+			}
+			nearest := orb.Point{a.Lon() + t*(b.Lon()-a.Lon()), a.Lat() + t*(b.Lat()-a.Lat())}
+			if distance := geo.Distance(point, nearest); distance < nearestDistance {
+				found, nearestDistance = true, distance
+				left = dx*py-dy*px > 0
+			}
+		}
+	}
+	return nearestDistance, left, found
+}
+
+// withContext of administrative hierarchy and display name set to places if SearchConfig.Context is enabled.
+// Units are loaded by scanning all tiles once.
+func (m *Manager) withContext(places Places, err error) (Places, error) {
+	_, contexts := m.searchIndexes()
+	if err != nil || !contexts.cfg.Context || len(places) == 0 {
+		return places, err
+	}
+
+	// Places are found anyway
+	if err := contexts.load(m); err != nil {
+		logrus.WithError(err).Warn("Unable to load administrative context")
+		return places, nil
+	}
+	for _, place := range places {
+		place.Context = contexts.chain(place)
+		var names []string
+		for _, item := range place.Context {
+			if item.Name != "" {
+				names = append(names, item.Name)
+			}
+		}
+		place.DisplayName = strings.Join(names, ", ")
+	}
+	return places, nil
+}
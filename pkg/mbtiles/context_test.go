@@ -0,0 +1,83 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+// testContextFeatures of Canary Islands administrative hierarchy
+var testContextFeatures = append([]testFeature{
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.6291, 28.2916}, properties: geojson.Properties{
+		"name": "Tenerife", "class": "island",
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.2550, 28.4600}, properties: geojson.Properties{
+		"name": "Santa Cruz de Tenerife", "class": "province",
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-15.4300, 28.1000}, properties: geojson.Properties{
+		"name": "Las Palmas", "class": "province",
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-15.7000, 28.1000}, properties: geojson.Properties{
+		"name": "Canarias", "class": "state",
+	}},
+	{layer: "boundary", zoom: 14, geometry: orb.LineString{{-18.5, 27.5}, {-18.5, 29.5}}, properties: geojson.Properties{
+		"admin_level": 2.0, "adm0_l": "Portugal", "adm0_r": "España", "maritime": 1.0,
+	}},
+}, testPlaceFeatures...)
+
+func TestPlaceContext(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testContextFeatures))
+	require.NoError(t, err)
+
+	// Context is opt-in
+	places, err := m.Search("ursula", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Nil(t, places[0].Context)
+	require.Empty(t, places[0].DisplayName)
+
+	cfg := m.SearchConfig()
+	cfg.Context = true
+	m.SetSearchConfig(cfg)
+	places, err = m.Search("ursula", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, []ContextItem{
+		{Name: "Santa Úrsula", Class: "town"},
+		{Name: "Tenerife", Class: "island"},
+		{Name: "Santa Cruz de Tenerife", Class: "province"},
+		{Name: "Canarias", Class: "state"},
+		{Name: "España", Class: "country"},
+	}, places[0].Context)
+	require.Equal(t, "Santa Úrsula, Tenerife, Santa Cruz de Tenerife, Canarias, España", places[0].DisplayName)
+
+	// Units far from the place are skipped
+	places, err = m.Search("las palmas de gran", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Las Palmas de Gran Canaria, Las Palmas, Canarias, España", places[0].DisplayName)
+
+	// Context units aren't repeated
+	places, err = m.Search("tenerife", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "island", places[0].Class)
+	require.Equal(t, "Tenerife, Santa Cruz de Tenerife, Canarias, España", places[0].DisplayName)
+
+	fc := places.FeatureCollection()
+	require.Equal(t, places[0].DisplayName, fc.Features[0].Properties[DisplayNameProperty])
+}
+
+func TestLineSide(t *testing.T) {
+	line := orb.LineString{{0, 0}, {0, 1}, {0, 2}}
+	distance, left, ok := lineSide(line, orb.Point{-0.1, 1.5})
+	require.True(t, ok)
+	require.True(t, left)
+	require.InDelta(t, 11120, distance, 10)
+
+	_, left, ok = lineSide(orb.MultiLineString{line}, orb.Point{0.1, 0.5})
+	require.True(t, ok)
+	require.False(t, left)
+}
@@ -2,41 +2,402 @@ package mbtiles
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
 	"errors"
+	"io"
 )
 
-// TileFormat of a tile
+// TileFormat of a tile content
 type TileFormat int
 
 // List of possible formats
 const (
-	UNKNOWN = iota
-	GZIP
-	ZLIB
+	UNKNOWN TileFormat = iota
+	PBF
 	PNG
 	JPG
 	WEBP
+	GIF
+	AVIF
+	JSON
+	UTFGRID
+)
+
+// TileEncoding of a tile content compression.
+// Brotli has no signature, such tiles are detected as not compressed content of unknown format.
+type TileEncoding int
+
+// List of possible encodings
+const (
+	IDENTITY TileEncoding = iota
+	GZIP
+	ZLIB
+	ZSTD
 )
 
 // ErrUnknownTileFormat an error of unknown tile format
 var ErrUnknownTileFormat = errors.New("could not detect tile format")
 
-// TypeFormatPatterns of any possible tile
+// TypeFormatPatterns of data prefixes of image formats.
+//
+// Deprecated: use DetectTile. GZIP and ZLIB are TileEncoding values now,
+// so compressed tiles have no pattern here, their content format is detected instead.
 var TypeFormatPatterns = map[TileFormat][]byte{
-	GZIP: []byte("\x1f\x8b"), // this masks PBF format too
-	ZLIB: []byte("\x78\x9c"),
-	PNG:  []byte("\x89\x50\x4E\x47\x0D\x0A\x1A\x0A"),
-	JPG:  []byte("\xFF\xD8\xFF"),
-	WEBP: []byte("\x52\x49\x46\x46"),
+	PNG:  []byte("\x89PNG\r\n\x1a\n"),
+	JPG:  []byte("\xff\xd8\xff"),
+	GIF:  []byte("GIF8"),
+	WEBP: []byte("RIFF"),
+}
+
+// detectPeekSize of decompressed content enough to detect its format
+const detectPeekSize = 512
+
+// detectPrefixSize of compressed tile data enough to decompress detectPeekSize bytes
+const detectPrefixSize = 4096
+
+// TileInfo of detected tile content type and encoding
+type TileInfo struct {
+	Format   TileFormat
+	Encoding TileEncoding
+}
+
+// tileSignature of a format by data prefix
+type tileSignature struct {
+	format TileFormat
+	match  func(data []byte) bool
+}
+
+// tileSignatures checked in order, more specific ones first
+var tileSignatures = []tileSignature{
+	{PNG, hasPrefix("\x89PNG\r\n\x1a\n")},
+	{JPG, hasPrefix("\xff\xd8\xff")},
+	{GIF, func(data []byte) bool {
+		return bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a"))
+	}},
+	{WEBP, func(data []byte) bool {
+		return len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+	}},
+	{AVIF, isAVIF},
+	{UTFGRID, func(data []byte) bool {
+		return isJSON(data) && bytes.Contains(data, []byte(`"grid"`))
+	}},
+	{JSON, isJSON},
+	{PBF, isVectorTile},
+}
+
+// tileEncodings detected by data prefix
+var tileEncodings = []struct {
+	encoding TileEncoding
+	match    func(data []byte) bool
+}{
+	{GZIP, hasPrefix("\x1f\x8b")},
+	{ZLIB, func(data []byte) bool {
+		// Deflate method with header checksum
+		return len(data) >= 2 && data[0]&0x0f == 8 && data[0]>>4 <= 7 && binary.BigEndian.Uint16(data)%31 == 0
+	}},
+	{ZSTD, hasPrefix("\x28\xb5\x2f\xfd")},
+}
+
+// DetectTile content type and encoding by data.
+// Compressed content is peeked to detect its format,
+// zstd content can't be decoded, so its format is UNKNOWN with ErrUnknownTileFormat.
+// Brotli has no signature, such tiles are detected as UNKNOWN.
+func DetectTile(data []byte) (TileInfo, error) {
+	if len(data) == 0 {
+		return TileInfo{}, ErrEmptyTileData
+	}
+	info := TileInfo{Encoding: IDENTITY}
+	for _, e := range tileEncodings {
+		if e.match(data) {
+			info.Encoding = e.encoding
+			break
+		}
+	}
+
+	content := data
+	switch info.Encoding {
+	case GZIP, ZLIB:
+		peek, err := peekContent(data, info.Encoding)
+		if err != nil {
+			return info, err
+		}
+		content = peek
+	case ZSTD:
+		return info, ErrUnknownTileFormat
+	}
+
+	for _, signature := range tileSignatures {
+		if signature.match(content) {
+			info.Format = signature.format
+			return info, nil
+		}
+	}
+	return info, ErrUnknownTileFormat
 }
 
-// DetectTileFormat by data slice
+// DetectTileFormat of content by data slice, compressed or not.
+// Compressed content is detected by its format, e.g. gzip compressed vector tiles are PBF,
+// not GZIP as before GZIP and ZLIB became TileEncoding values, use DetectTile to get the encoding.
 func DetectTileFormat(data []byte) (TileFormat, error) {
-	for format, pattern := range TypeFormatPatterns {
-		if bytes.HasPrefix(data, pattern) {
-			return format, nil
+	info, err := DetectTile(data)
+	return info.Format, err
+}
+
+// peekContent decompresses beginning of the data
+func peekContent(data []byte, encoding TileEncoding) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case GZIP:
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case ZLIB:
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	peek := make([]byte, detectPeekSize)
+	n, err := io.ReadFull(reader, peek)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return peek[:n], nil
+}
+
+// Decode tile data into uncompressed content
+func (info TileInfo) Decode(data []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch info.Encoding {
+	case IDENTITY:
+		return data, nil
+	case GZIP:
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case ZLIB:
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return nil, errors.New("unsupported tile encoding " + info.ContentEncoding())
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// MimeType of tile content
+func (info TileInfo) MimeType() string {
+	switch info.Format {
+	case PBF:
+		return "application/x-protobuf"
+	case PNG:
+		return "image/png"
+	case JPG:
+		return "image/jpeg"
+	case WEBP:
+		return "image/webp"
+	case GIF:
+		return "image/gif"
+	case AVIF:
+		return "image/avif"
+	case JSON, UTFGRID:
+		return "application/json"
+	}
+	return "application/octet-stream"
+}
+
+// Extension of tile file without leading dot
+func (info TileInfo) Extension() string {
+	switch info.Format {
+	case PNG:
+		return "png"
+	case JPG:
+		return "jpg"
+	case WEBP:
+		return "webp"
+	case GIF:
+		return "gif"
+	case AVIF:
+		return "avif"
+	case JSON, UTFGRID:
+		return "json"
+	}
+	return "pbf"
+}
+
+// ContentEncoding header value, empty for not compressed content
+func (info TileInfo) ContentEncoding() string {
+	switch info.Encoding {
+	case GZIP:
+		return "gzip"
+	case ZLIB:
+		return "deflate"
+	case ZSTD:
+		return "zstd"
+	}
+	return ""
+}
+
+// String name of the format
+func (f TileFormat) String() string {
+	switch f {
+	case PBF:
+		return "pbf"
+	case PNG:
+		return "png"
+	case JPG:
+		return "jpg"
+	case WEBP:
+		return "webp"
+	case GIF:
+		return "gif"
+	case AVIF:
+		return "avif"
+	case JSON:
+		return "json"
+	case UTFGRID:
+		return "utfgrid"
+	}
+	return "unknown"
+}
+
+// String name of the encoding
+func (e TileEncoding) String() string {
+	switch e {
+	case GZIP:
+		return "gzip"
+	case ZLIB:
+		return "zlib"
+	case ZSTD:
+		return "zstd"
+	}
+	return "identity"
+}
+
+// String of format followed by encoding of compressed content, e.g. pbf+gzip
+func (info TileInfo) String() string {
+	if info.Encoding == IDENTITY {
+		return info.Format.String()
+	}
+	return info.Format.String() + "+" + info.Encoding.String()
+}
+
+// hasPrefix signature matcher
+func hasPrefix(prefix string) func(data []byte) bool {
+	return func(data []byte) bool {
+		return bytes.HasPrefix(data, []byte(prefix))
+	}
+}
+
+// isAVIF by ftyp box with avif brand
+func isAVIF(data []byte) bool {
+	if len(data) < 16 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	size := int(binary.BigEndian.Uint32(data))
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+
+	// Major brand followed by minor version and compatible brands
+	for i := 8; i+4 <= size; i += 4 {
+		if i == 12 {
+			continue
+		}
+		if brand := string(data[i : i+4]); brand == "avif" || brand == "avis" {
+			return true
 		}
 	}
+	return false
+}
+
+// isJSON object or array
+func isJSON(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
 
-	return UNKNOWN, ErrUnknownTileFormat
+// isVectorTile probes protobuf structure of Mapbox vector tile.
+// The tile consists of layers (field 3) having known fields only,
+// data may be truncated.
+func isVectorTile(data []byte) bool {
+	layers := 0
+	for len(data) > 0 {
+		field, wireType, n := protoTag(data)
+		if n == 0 || field != 3 || wireType != 2 {
+			return false
+		}
+		data = data[n:]
+		size, n := protoVarint(data)
+		if n == 0 {
+			return false
+		}
+		data = data[n:]
+		layer := data
+		if size < uint64(len(data)) {
+			layer = data[:size]
+		}
+		if !isVectorLayer(layer) {
+			return false
+		}
+		layers++
+		data = data[len(layer):]
+	}
+	return layers > 0
+}
+
+// isVectorLayer probes fields of possibly truncated layer message
+func isVectorLayer(data []byte) bool {
+	fields := 0
+	for len(data) > 0 {
+		field, wireType, n := protoTag(data)
+		if n == 0 {
+			return fields > 0
+		}
+		switch {
+		case (field >= 1 && field <= 4) && wireType == 2:
+		case (field == 5 || field == 15) && wireType == 0:
+		default:
+			return false
+		}
+		data = data[n:]
+		value, n := protoVarint(data)
+		if n == 0 {
+			return fields > 0
+		}
+		data = data[n:]
+		if wireType == 2 {
+			if value > uint64(len(data)) {
+				// Truncated data
+				return true
+			}
+			data = data[value:]
+		}
+		fields++
+	}
+	return fields > 0
+}
+
+// protoTag of field, zero length if it isn't valid
+func protoTag(data []byte) (uint64, uint64, int) {
+	tag, n := protoVarint(data)
+	if n == 0 || tag>>3 == 0 {
+		return 0, 0, 0
+	}
+	return tag >> 3, tag & 7, n
+}
+
+// protoVarint value and its length, zero length if it isn't valid
+func protoVarint(data []byte) (uint64, int) {
+	value, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, 0
+	}
+	return value, n
 }
@@ -0,0 +1,76 @@
+package mbtiles
+
+import (
+	"bytes"
+	"compress/zlib"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTile(t *testing.T) {
+	fc := geojson.NewFeatureCollection()
+	feature := geojson.NewFeature(orb.Point{10, 10})
+	feature.Properties["name"] = "Teide"
+	fc.Append(feature)
+	pbf, err := mvt.Marshal(mvt.NewLayers(map[string]*geojson.FeatureCollection{"place": fc}))
+	require.NoError(t, err)
+	gzipped, err := gzipData(pbf)
+	require.NoError(t, err)
+
+	grid := []byte(`{"grid":["  "],"keys":[""],"data":{}}`)
+	var deflated bytes.Buffer
+	writer := zlib.NewWriter(&deflated)
+	_, err = writer.Write(grid)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	for name, test := range map[string]struct {
+		data []byte
+		info TileInfo
+	}{
+		"raw pbf":     {pbf, TileInfo{PBF, IDENTITY}},
+		"gzipped pbf": {gzipped, TileInfo{PBF, GZIP}},
+		"zlib grid":   {deflated.Bytes(), TileInfo{UTFGRID, ZLIB}},
+		"json":        {[]byte(` {"type":"FeatureCollection"}`), TileInfo{JSON, IDENTITY}},
+		"png":         {[]byte("\x89PNG\r\n\x1a\n\x00\x00"), TileInfo{PNG, IDENTITY}},
+		"jpg":         {[]byte("\xff\xd8\xff\xe0\x00\x10JFIF"), TileInfo{JPG, IDENTITY}},
+		"gif":         {[]byte("GIF89a\x01\x00"), TileInfo{GIF, IDENTITY}},
+		"webp":        {[]byte("RIFF\x24\x00\x00\x00WEBPVP8 "), TileInfo{WEBP, IDENTITY}},
+		"avif":        {[]byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00avifmif1miaf"), TileInfo{AVIF, IDENTITY}},
+	} {
+		info, err := DetectTile(test.data)
+		require.NoError(t, err, name)
+		require.Equal(t, test.info, info, name)
+	}
+
+	// Zstandard content isn't inspected
+	info, err := DetectTile([]byte("\x28\xb5\x2f\xfd\x00\x00"))
+	require.ErrorIs(t, err, ErrUnknownTileFormat)
+	require.Equal(t, TileInfo{UNKNOWN, ZSTD}, info)
+	require.Equal(t, "zstd", info.ContentEncoding())
+
+	// RIFF container of other content isn't WEBP
+	_, err = DetectTile([]byte("RIFF\x24\x00\x00\x00WAVEfmt "))
+	require.ErrorIs(t, err, ErrUnknownTileFormat)
+
+	_, err = DetectTile(nil)
+	require.ErrorIs(t, err, ErrEmptyTileData)
+}
+
+func TestTileInfo(t *testing.T) {
+	info := TileInfo{PBF, GZIP}
+	require.Equal(t, "application/x-protobuf", info.MimeType())
+	require.Equal(t, "pbf", info.Extension())
+	require.Equal(t, "gzip", info.ContentEncoding())
+
+	info = TileInfo{WEBP, IDENTITY}
+	require.Equal(t, "image/webp", info.MimeType())
+	require.Equal(t, "webp", info.Extension())
+	require.Empty(t, info.ContentEncoding())
+
+	require.Equal(t, "deflate", TileInfo{UTFGRID, ZLIB}.ContentEncoding())
+}
@@ -1,20 +1,14 @@
 package mbtiles
 
 import (
-	"bytes"
-	"compress/gzip"
-	"compress/zlib"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
-	"strconv"
-	"strings"
+	"runtime"
 	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/mitchellh/mapstructure"
 	_ "github.com/mitchellh/mapstructure"
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +18,10 @@ type ExporterSettings struct {
 	Path       string
 	Decompress bool
 	BaseUrl    string
+
+	// Number of concurrent tile writers, runtime.NumCPU() by default.
+	// Bounds open files and tiles held in memory.
+	Workers int
 }
 
 // Exporter of mbtiles
@@ -31,9 +29,10 @@ type Exporter struct {
 	Manager
 
 	cfg ExporterSettings
-	wg  sync.WaitGroup
 
-	tiles      []*Tile
+	// Extension of tiles which format can't be detected, taken from metadata
+	format string
+
 	TilesCount int
 }
 
@@ -43,6 +42,9 @@ func NewExporter(importPath string, settings ExporterSettings) (*Exporter, error
 	if err != nil {
 		return nil, err
 	}
+	if settings.Workers < 1 {
+		settings.Workers = runtime.NumCPU()
+	}
 	return &Exporter{
 		Manager: *manager,
 		cfg:     settings,
@@ -52,28 +54,49 @@ func NewExporter(importPath string, settings ExporterSettings) (*Exporter, error
 // Export tiles
 func (ex *Exporter) Export() error {
 	var err error
+	ctx := context.Background()
 
-	// Fetch tiles
-	if ex.tiles, err = ex.GetTiles(); err != nil {
+	// Count tiles
+	if ex.TilesCount, err = ex.CountTiles(ctx, TileFilter{}); err != nil {
 		return err
 	}
 
-	ex.TilesCount = len(ex.tiles)
-	ex.wg.Add(ex.TilesCount)
-	for id, tile := range ex.tiles {
-		tile.Number = id + 1
-		go ex.exportTile(tile)
-	}
-	ex.wg.Wait()
-
 	// Get meta
 	meta, err := ex.GetMeta()
 	if err != nil {
 		return err
 	}
+	ex.format = meta.Format
+
+	// Stream tiles to a bounded pool of workers.
+	// Unbuffered channel blocks the reader until a worker is free.
+	tiles := make(chan *Tile)
+	var wg sync.WaitGroup
+	wg.Add(ex.cfg.Workers)
+	for i := 0; i < ex.cfg.Workers; i++ {
+		go func() {
+			defer wg.Done()
+			for tile := range tiles {
+				ex.exportTile(tile)
+			}
+		}()
+	}
+
+	number := 0
+	err = ex.WalkTiles(ctx, TileFilter{}, func(tile *Tile) bool {
+		number++
+		tile.Number = number
+		tiles <- tile
+		return true
+	})
+	close(tiles)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
 
-	// Generate JSON from meta
-	indexJson, err := json.Marshal(meta)
+	// Generate TileJSON from meta
+	indexJson, err := json.Marshal(meta.TileJSON(ex.cfg.BaseUrl))
 	if err != nil {
 		return err
 	}
@@ -95,8 +118,6 @@ func (ex *Exporter) Export() error {
 
 // exportTile
 func (ex *Exporter) exportTile(t *Tile) {
-	defer ex.wg.Done()
-
 	// Create tilesPath
 	tilesPath := fmt.Sprintf("%s/%s", ex.cfg.Path, t.GetPath())
 	if err := os.MkdirAll(tilesPath, 0750); err != nil {
@@ -113,47 +134,33 @@ func (ex *Exporter) exportTile(t *Tile) {
 	// }
 
 	// Detect tile data
-	var tileDataReader io.Reader
-	tileDataReader = bytes.NewReader(t.Data)
-	fileType := "pbf"
-	format, err := t.DetectTileFormat()
+	info, err := t.Detect()
 	if err != nil {
 		logrus.
 			WithField("tile", t).
 			Warn("Detect tile format")
 	}
 
-	// Decompress depending on the format
-	switch format {
-	case GZIP:
-		if ex.cfg.Decompress {
-			tileDataReader, _ = gzip.NewReader(tileDataReader)
-		}
-	case ZLIB:
-		if ex.cfg.Decompress {
-			tileDataReader, _ = zlib.NewReader(tileDataReader)
-		}
-	case JPG:
-		fileType = "jpg"
-	case PNG:
-		fileType = "png"
-	case WEBP:
-		fileType = "webp"
+	// Defile tile file name, e.g. of zstd compressed tiles by metadata format
+	extension := info.Extension()
+	if info.Format == UNKNOWN && ex.format != "" {
+		extension = ex.format
 	}
+	tileFileName := fmt.Sprintf("%s/%d.%s", tilesPath, t.GetFileName(), extension)
 
-	// Defile tile file name
-	tileFileName := fmt.Sprintf("%s/%d.%s", tilesPath, t.GetFileName(), fileType)
-
-	// Read tile data
-	pbf, err := ioutil.ReadAll(tileDataReader)
-	if err != nil {
-		logrus.
-			WithField("path", tileFileName).
-			WithError(err).Error("Read tile data")
+	// Decompress depending on the encoding
+	data := t.Data
+	if ex.cfg.Decompress && info.Encoding != IDENTITY {
+		if data, err = info.Decode(t.Data); err != nil {
+			logrus.
+				WithField("path", tileFileName).
+				WithError(err).Error("Read tile data")
+			data = t.Data
+		}
 	}
 
 	// Write tile file
-	if err := os.WriteFile(tileFileName, pbf, 0600); err != nil {
+	if err := os.WriteFile(tileFileName, data, 0600); err != nil {
 		logrus.
 			WithField("path", tileFileName).
 			WithError(err).Error("Write tile file")
@@ -170,50 +177,12 @@ func (ex *Exporter) exportTile(t *Tile) {
 		Info("Extract tile file")
 }
 
-// GetMeta data from database file
+// GetMeta data from database file with tiles URL template of exported files
 func (ex *Exporter) GetMeta() (*Meta, error) {
-	rows, err := ex.db.Queryx("SELECT name,value FROM metadata")
+	meta, err := ex.Manager.GetMeta()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	metaMap := map[string]string{}
-	for rows.Next() {
-		var k, v string
-		err = rows.Scan(&k, &v)
-		metaMap[k] = v
-	}
-
-	meta := &Meta{
-		Scheme:   "xyz",
-		Type:     "baselayer",
-		TileJson: "2.0.0",
-		Format:   "pbf",
-		Basename: "base",
-		Profile:  "mercator",
-		Scale:    1,
-		Tiles:    []string{fmt.Sprintf("%s/{z}/{x}/{y}.pbf", ex.cfg.BaseUrl)},
-		Bounds:   stringToFloatArray(metaMap["bounds"]),
-		Center:   stringToFloatArray(metaMap["center"]),
-	}
-
-	if err := json.Unmarshal([]byte(metaMap["json"]), meta); err != nil {
-		return nil, err
-	}
-
-	if err := mapstructure.WeakDecode(&metaMap, meta); err != nil {
-		return nil, err
-	}
+	meta.Tiles = meta.TileJSON(ex.cfg.BaseUrl).Tiles
 	return meta, nil
 }
-
-func stringToFloatArray(floats string) []float64 {
-	split := strings.Split(floats, ",")
-	var bounds = make([]float64, len(split))
-	for i, bound := range split {
-		bf, _ := strconv.ParseFloat(bound, 32)
-		bounds[i] = bf
-	}
-	return bounds
-}
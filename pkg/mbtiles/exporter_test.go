@@ -0,0 +1,47 @@
+package mbtiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	path := t.TempDir()
+	ex, err := NewExporter(testTilesPath, ExporterSettings{
+		Path:       path,
+		Decompress: true,
+		BaseUrl:    "http://localhost/tiles",
+		Workers:    2,
+	})
+	require.NoError(t, err, "can't read mbtiles file")
+	require.NoError(t, ex.Export())
+	require.Equal(t, 985, ex.TilesCount)
+
+	files, err := filepath.Glob(filepath.Join(path, "*", "*", "*.pbf"))
+	require.NoError(t, err)
+	require.Len(t, files, ex.TilesCount)
+
+	_, err = os.Stat(filepath.Join(path, "index.json"))
+	require.NoError(t, err, "index file isn't written")
+}
+
+func TestExportUndetectedFormat(t *testing.T) {
+	// Zstandard compressed tiles are named by metadata format
+	tilesPath := filepath.Join(t.TempDir(), "test.mbtiles")
+	writer, err := NewWriter(tilesPath, WriterSettings{})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteTile(1, 0, 0, []byte("\x28\xb5\x2f\xfd\x00\x00")))
+	require.NoError(t, writer.WriteMeta(&Meta{Name: "test", Format: "png", MaxZoom: 1}))
+	require.NoError(t, writer.Close())
+
+	path := t.TempDir()
+	ex, err := NewExporter(tilesPath, ExporterSettings{Path: path, Workers: 1})
+	require.NoError(t, err)
+	require.NoError(t, ex.Export())
+	files, err := filepath.Glob(filepath.Join(path, "*", "*", "*.png"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
@@ -0,0 +1,98 @@
+package mbtiles
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
+)
+
+// testFeature written into test tiles
+type testFeature struct {
+	layer      string
+	zoom       maptile.Zoom
+	geometry   orb.Geometry
+	properties geojson.Properties
+
+	// Feature ID, none by default
+	id interface{}
+
+	// Tile to write the feature into, tile at geometry center by default
+	tile *maptile.Tile
+}
+
+// testPlaceFeatures of Tenerife in OpenMapTiles schema
+var testPlaceFeatures = []testFeature{
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.2518, 28.4636}, properties: geojson.Properties{
+		"name": "Santa Cruz de Tenerife", "name:latin": "Santa Cruz de Tenerife", "class": "city", "rank": 4.0,
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.3159, 28.4853}, properties: geojson.Properties{
+		"name": "San Cristóbal de La Laguna", "name:latin": "San Cristóbal de La Laguna", "class": "city", "rank": 5.0,
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.4891, 28.4260}, properties: geojson.Properties{
+		"name": "Santa Úrsula", "name:latin": "Santa Úrsula", "class": "town", "rank": 9.0,
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.5490, 28.4142}, properties: geojson.Properties{
+		"name": "Puerto de la Cruz", "name:latin": "Puerto de la Cruz", "class": "town", "rank": 8.0,
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.5712, 28.3804}, properties: geojson.Properties{
+		"name": "Santa Bárbara", "name:latin": "Santa Bárbara", "class": "hamlet", "rank": 14.0,
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-15.4134, 28.1235}, properties: geojson.Properties{
+		"name": "Las Palmas de Gran Canaria", "name:latin": "Las Palmas de Gran Canaria", "class": "city", "rank": 4.0,
+	}},
+}
+
+// writeTestTiles into a new MBTiles file
+func writeTestTiles(t *testing.T, features []testFeature) string {
+	type tileLayers map[string]*geojson.FeatureCollection
+	tiles := map[maptile.Tile]tileLayers{}
+	maxZoom := 0
+	for _, f := range features {
+		if int(f.zoom) > maxZoom {
+			maxZoom = int(f.zoom)
+		}
+		tile := maptile.At(f.geometry.Bound().Center(), f.zoom)
+		if f.tile != nil {
+			tile = *f.tile
+		}
+		if tiles[tile] == nil {
+			tiles[tile] = tileLayers{}
+		}
+		if tiles[tile][f.layer] == nil {
+			tiles[tile][f.layer] = geojson.NewFeatureCollection()
+		}
+		feature := geojson.NewFeature(orb.Clone(f.geometry))
+		feature.Properties = f.properties.Clone()
+		feature.ID = f.id
+		tiles[tile][f.layer].Append(feature)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	writer, err := NewWriter(path, WriterSettings{})
+	require.NoError(t, err)
+	for tile, layers := range tiles {
+		mvtLayers := mvt.NewLayers(layers)
+		mvtLayers.ProjectToTile(tile)
+		data, err := mvt.MarshalGzipped(mvtLayers)
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteTile(int64(tile.Z), int64(tile.X), FlipRow(int64(tile.Z), int64(tile.Y)), data))
+	}
+	require.NoError(t, writer.WriteMeta(&Meta{
+		Name:    "test",
+		Format:  "pbf",
+		MinZoom: 0,
+		MaxZoom: maxZoom,
+		Bounds:  []float64{-18.2, 27.6, -13.4, 29.5},
+		Center:  []float64{-16.25, 28.46, float64(maxZoom)},
+		VectorLayers: []VectorLayer{
+			{ID: "place", MinZoom: 0, MaxZoom: 14},
+		},
+	}))
+	require.NoError(t, writer.Close())
+	return path
+}
@@ -0,0 +1,140 @@
+package mbtiles
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// DefaultFuzzyThreshold of names similarity in fuzzy search mode
+const DefaultFuzzyThreshold = 0.75
+
+// fuzzyMatcher of names tolerating spelling mistakes
+type fuzzyMatcher struct {
+	// Collation matcher, exact matches are always found
+	exact *matcher
+
+	// Normalized query words
+	words []string
+
+	// Minimal similarity from 0 to 1
+	threshold float64
+}
+
+// newFuzzyMatcher of query
+func newFuzzyMatcher(query string, threshold float64) *fuzzyMatcher {
+	if threshold <= 0 {
+		threshold = DefaultFuzzyThreshold
+	}
+	return &fuzzyMatcher{
+		exact:     newMatcher(query),
+		words:     strings.Fields(normalizeName(query)),
+		threshold: threshold,
+	}
+}
+
+// match name exactly or by similarity of query to any sequence of name words
+func (fm *fuzzyMatcher) match(name string) (float64, bool) {
+	if score, ok := fm.exact.match(name); ok {
+		return score, true
+	}
+	if len(fm.words) == 0 {
+		return 0, false
+	}
+
+	query := strings.Join(fm.words, " ")
+	nameWords := strings.Fields(normalizeName(name))
+	best := 0.0
+	for start := range nameWords {
+		end := start + len(fm.words)
+		if end > len(nameWords) {
+			break
+		}
+		similarity := nameSimilarity(query, strings.Join(nameWords[start:end], " "))
+		if similarity < fm.threshold {
+			continue
+		}
+
+		// Similar whole name is the best, then similar first words
+		var score float64
+		switch {
+		case start == 0 && end == len(nameWords):
+			score = similarity * ExactMatchScore
+		case start == 0:
+			score = similarity * PrefixMatchScore
+		default:
+			score = similarity * WordPrefixMatchScore
+		}
+		if score > best {
+			best = score
+		}
+	}
+	return best, best > 0
+}
+
+// normalizer folds width and removes diacritics
+var normalizer = transform.Chain(width.Fold, norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// normalizeName for fuzzy comparison: lower case without diacritics and punctuation
+func normalizeName(name string) string {
+	normalized, _, err := transform.String(normalizer, name)
+	if err != nil {
+		normalized = name
+	}
+	return strings.Join(strings.FieldsFunc(strings.ToLower(normalized), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
+
+// nameSimilarity from 0 to 1 by edit distance
+func nameSimilarity(a string, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance of Damerau-Levenshtein with adjacent transpositions
+func editDistance(a []rune, b []rune) int {
+	// Three rows of distances matrix are enough
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = minInt(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return previous[len(b)]
+}
+
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
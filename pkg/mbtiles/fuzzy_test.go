@@ -0,0 +1,93 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"tenerife", "tenerife", 0},
+		{"tenerfe", "tenerife", 1},
+		{"laguan", "laguna", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		require.Equal(t, c.distance, editDistance([]rune(c.a), []rune(c.b)), c.a+"/"+c.b)
+	}
+	require.Equal(t, "santa ursula", normalizeName("Santa-Úrsula!"))
+}
+
+func TestFuzzySearch(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+
+	// Misspelled names aren't found exactly
+	places, err := m.Search("Tenerfe", 5)
+	require.NoError(t, err)
+	require.Empty(t, places)
+
+	cfg := m.SearchConfig()
+	cfg.Mode = FuzzyMode
+	m.SetSearchConfig(cfg)
+
+	places, err = m.Search("Tenerfe", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Santa Cruz de Tenerife", places[0].Properties["name"])
+
+	places, err = m.Search("Laguan", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "San Cristóbal de La Laguna", places[0].Properties["name"])
+
+	// Exact matches are ranked above similar ones
+	places, err = m.Search("santa", 5)
+	require.NoError(t, err)
+	require.Equal(t, "Santa Cruz de Tenerife", places[0].Properties["name"])
+
+	cfg.Mode = "unknown"
+	m.SetSearchConfig(cfg)
+	_, err = m.Search("santa", 5)
+	require.ErrorIs(t, err, ErrUnknownSearchMode)
+}
+
+func TestPhoneticKey(t *testing.T) {
+	for name, key := range map[string]string{
+		"Lagoona":           "laguna",
+		"Laguna":            "laguna",
+		"Puerto de la Crus": "puerto de la krus",
+		"Puerto de la Cruz": "puerto de la krus",
+		"Guía de Isora":     "gia de isora",
+		"Villa Gerra":       "biya jera",
+		"Chío":              "ʧio",
+		"Shio":              "ʧio",
+		"Kio":               "kio",
+		"Quío":              "kio",
+	} {
+		require.Equal(t, key, phoneticKey(name), name)
+	}
+}
+
+func TestPhoneticSearch(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+	cfg := m.SearchConfig()
+	cfg.Mode = PhoneticMode
+	m.SetSearchConfig(cfg)
+
+	places, err := m.Search("Lagoona", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "San Cristóbal de La Laguna", places[0].Properties["name"])
+
+	places, err = m.Search("Puerto de la Crus", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Puerto de la Cruz", places[0].Properties["name"])
+}
@@ -0,0 +1,234 @@
+package mbtiles
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+)
+
+// Schema flavours of MBTiles file
+const (
+	// FlatSchema stores tiles in the tiles table
+	FlatSchema = "flat"
+
+	// DeduplicatedSchema stores unique tile images referred by map table through the tiles view
+	DeduplicatedSchema = "deduplicated"
+
+	// UnknownSchema of tiles view over other tables
+	UnknownSchema = "unknown"
+)
+
+// ErrNoTilesTable of file which isn't MBTiles
+var ErrNoTilesTable = errors.New("tiles table not found")
+
+// Info of MBTiles file for inspection
+type Info struct {
+	// Metadata, nil if it can't be read
+	Meta *Meta `json:"metadata,omitempty"`
+
+	// Schema flavour
+	Schema string `json:"schema"`
+
+	// Sizes of all tiles
+	Tiles TileSizeStats `json:"tiles"`
+
+	// Sizes of tiles by zoom level
+	Zooms []ZoomInfo `json:"zooms"`
+
+	// Tiles count by detected format, e.g. pbf+gzip
+	Formats map[string]int `json:"formats"`
+
+	// Largest tiles, the largest first
+	Largest []TileSize `json:"largest"`
+}
+
+// TileSizeStats of tiles data in bytes
+type TileSizeStats struct {
+	Count int     `json:"count"`
+	Bytes int64   `json:"bytes"`
+	Min   int     `json:"min"`
+	Avg   float64 `json:"avg"`
+	Max   int     `json:"max"`
+	P95   int     `json:"p95"`
+}
+
+// ZoomInfo of tiles at zoom level
+type ZoomInfo struct {
+	Zoom int64 `json:"zoom"`
+	TileSizeStats
+}
+
+// TileSize of tile data in XYZ scheme
+type TileSize struct {
+	Z    int64 `json:"z"`
+	X    int64 `json:"x"`
+	Y    int64 `json:"y"`
+	Size int   `json:"size"`
+}
+
+// Info of metadata, schema, tile sizes and formats with the largest tiles up to the limit
+func (m *Manager) Info(ctx context.Context, largest int) (*Info, error) {
+	schema, err := m.Schema()
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{
+		Schema:  schema,
+		Zooms:   []ZoomInfo{},
+		Formats: map[string]int{},
+		Largest: []TileSize{},
+	}
+	if meta, err := m.GetMeta(); err == nil {
+		info.Meta = meta
+	}
+
+	// Sizes are stored once by zoom level, totals are merged from them
+	zoomSizes := map[int64][]int{}
+	rows, err := m.db.QueryxContext(ctx, `
+      SELECT "zoom_level", "tile_column", "tile_row", IFNULL(LENGTH("tile_data"), 0), SUBSTR("tile_data", 1, ?)
+      FROM "tiles"`, detectPrefixSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tile TileSize
+		var prefix []byte
+		if err := rows.Scan(&tile.Z, &tile.X, &tile.Y, &tile.Size, &prefix); err != nil {
+			return nil, err
+		}
+		zoomSizes[tile.Z] = append(zoomSizes[tile.Z], tile.Size)
+
+		format := "empty"
+		if tile.Size > 0 {
+			tileInfo, _ := DetectTile(prefix)
+			format = tileInfo.String()
+		}
+		info.Formats[format]++
+
+		if largest > 0 {
+			tile.Y = FlipRow(tile.Z, tile.Y)
+			info.Largest = addLargest(info.Largest, tile, largest)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var sorted [][]int
+	for zoom, sizes := range zoomSizes {
+		info.Zooms = append(info.Zooms, ZoomInfo{Zoom: zoom, TileSizeStats: newTileSizeStats(sizes)})
+		sorted = append(sorted, sizes)
+	}
+	sort.Slice(info.Zooms, func(i, j int) bool {
+		return info.Zooms[i].Zoom < info.Zooms[j].Zoom
+	})
+	info.Tiles = mergeTileSizeStats(info.Zooms, sorted)
+	return info, nil
+}
+
+// Schema flavour of tiles table
+func (m *Manager) Schema() (string, error) {
+	objects := map[string]string{}
+	rows, err := m.db.Queryx(`SELECT "name", "type" FROM "sqlite_master" WHERE "name" IN ('tiles', 'map', 'images')`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, objectType string
+		if err := rows.Scan(&name, &objectType); err != nil {
+			return "", err
+		}
+		objects[name] = objectType
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	switch {
+	case objects["tiles"] == "table":
+		return FlatSchema, nil
+	case objects["tiles"] == "view" && objects["map"] == "table" && objects["images"] == "table":
+		return DeduplicatedSchema, nil
+	case objects["tiles"] == "":
+		return "", ErrNoTilesTable
+	}
+	return UnknownSchema, nil
+}
+
+// addLargest tile keeping the list sorted and limited
+func addLargest(tiles []TileSize, tile TileSize, limit int) []TileSize {
+	if len(tiles) >= limit && tile.Size <= tiles[len(tiles)-1].Size {
+		return tiles
+	}
+	i := sort.Search(len(tiles), func(i int) bool {
+		return tiles[i].Size < tile.Size
+	})
+	tiles = append(tiles, TileSize{})
+	copy(tiles[i+1:], tiles[i:])
+	tiles[i] = tile
+	if len(tiles) > limit {
+		tiles = tiles[:limit]
+	}
+	return tiles
+}
+
+// newTileSizeStats of sizes sorted in place, p95 is of nearest rank
+func newTileSizeStats(sizes []int) TileSizeStats {
+	stats := TileSizeStats{Count: len(sizes)}
+	if len(sizes) == 0 {
+		return stats
+	}
+	sort.Ints(sizes)
+	for _, size := range sizes {
+		stats.Bytes += int64(size)
+	}
+	stats.Min = sizes[0]
+	stats.Max = sizes[len(sizes)-1]
+	stats.Avg = float64(stats.Bytes) / float64(len(sizes))
+	stats.P95 = sizes[p95Rank(len(sizes))-1]
+	return stats
+}
+
+// mergeTileSizeStats of zoom levels with their sorted sizes, p95 is found without merging the sizes
+func mergeTileSizeStats(zooms []ZoomInfo, sorted [][]int) TileSizeStats {
+	var stats TileSizeStats
+	for _, zoom := range zooms {
+		if zoom.Count == 0 {
+			continue
+		}
+		if stats.Count == 0 || zoom.Min < stats.Min {
+			stats.Min = zoom.Min
+		}
+		if zoom.Max > stats.Max {
+			stats.Max = zoom.Max
+		}
+		stats.Count += zoom.Count
+		stats.Bytes += zoom.Bytes
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+	stats.Avg = float64(stats.Bytes) / float64(stats.Count)
+
+	// The smallest size having enough sizes not greater than it
+	rank := p95Rank(stats.Count)
+	stats.P95 = stats.Min + sort.Search(stats.Max-stats.Min, func(i int) bool {
+		count := 0
+		for _, sizes := range sorted {
+			count += sort.SearchInts(sizes, stats.Min+i+1)
+		}
+		return count >= rank
+	})
+	return stats
+}
+
+// p95Rank of nearest rank percentile, one based
+func p95Rank(count int) int {
+	return int(math.Ceil(0.95 * float64(count)))
+}
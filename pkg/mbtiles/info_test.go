@@ -0,0 +1,71 @@
+package mbtiles
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfo(t *testing.T) {
+	manager, err := NewManager(testTilesPath)
+	require.NoError(t, err)
+	info, err := manager.Info(context.Background(), 3)
+	require.NoError(t, err)
+
+	require.Equal(t, FlatSchema, info.Schema)
+	require.NotNil(t, info.Meta)
+	require.Equal(t, 985, info.Tiles.Count)
+	require.Len(t, info.Zooms, 6)
+	require.Equal(t, 689, info.Zooms[5].Count)
+	require.Equal(t, map[string]int{"pbf+gzip": 985}, info.Formats)
+	require.LessOrEqual(t, info.Tiles.Min, info.Tiles.P95)
+	require.LessOrEqual(t, info.Tiles.P95, info.Tiles.Max)
+
+	// Root tile is the largest one
+	require.Len(t, info.Largest, 3)
+	require.Equal(t, TileSize{Z: 0, X: 0, Y: 0, Size: info.Tiles.Max}, info.Largest[0])
+	require.GreaterOrEqual(t, info.Largest[1].Size, info.Largest[2].Size)
+}
+
+func TestDeduplicatedSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deduplicated.mbtiles")
+	db, err := sqlx.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, query := range []string{
+		`CREATE TABLE "metadata" ("name" text, "value" text)`,
+		`CREATE TABLE "map" ("zoom_level" integer, "tile_column" integer, "tile_row" integer, "tile_id" text)`,
+		`CREATE TABLE "images" ("tile_data" blob, "tile_id" text)`,
+		`CREATE VIEW "tiles" AS SELECT "zoom_level", "tile_column", "tile_row", "tile_data"
+           FROM "map" JOIN "images" ON "images"."tile_id" = "map"."tile_id"`,
+		`INSERT INTO "images" VALUES (x'89504e470d0a1a0a', 'a')`,
+		`INSERT INTO "map" VALUES (1, 0, 0, 'a'), (1, 1, 0, 'a')`,
+	} {
+		_, err = db.Exec(query)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	manager, err := NewManager(path)
+	require.NoError(t, err)
+	info, err := manager.Info(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, DeduplicatedSchema, info.Schema)
+	require.Equal(t, map[string]int{"png": 2}, info.Formats)
+	require.Equal(t, int64(16), info.Tiles.Bytes)
+	require.Empty(t, info.Largest)
+}
+
+func TestMergeTileSizeStats(t *testing.T) {
+	sizes := [][]int{{5, 1, 9, 3}, {100, 2}, {}, {7, 7, 50, 8, 4, 6, 11, 12, 13, 14, 15, 16, 17, 18}}
+	var all []int
+	var zooms []ZoomInfo
+	for i, zoomSizes := range sizes {
+		all = append(all, zoomSizes...)
+		zooms = append(zooms, ZoomInfo{Zoom: int64(i), TileSizeStats: newTileSizeStats(zoomSizes)})
+	}
+	require.Equal(t, newTileSizeStats(all), mergeTileSizeStats(zooms, sizes))
+	require.Equal(t, TileSizeStats{}, mergeTileSizeStats(nil, nil))
+}
@@ -0,0 +1,205 @@
+package mbtiles
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+)
+
+// AllZoomLevels can be passed to WalkThroughTiles to walk every zoom level in one pass
+const AllZoomLevels = -1
+
+// TileRange of inclusive min and max values
+type TileRange struct {
+	Min int64
+	Max int64
+}
+
+// TileFilter restricts tiles fetched by Manager.Tiles.
+// A nil field means no restriction.
+type TileFilter struct {
+	// Zoom levels range
+	Zoom *TileRange
+
+	// Tile columns range
+	Column *TileRange
+
+	// Tile rows range, in TMS scheme as they are stored in the database
+	Row *TileRange
+
+	// WGS 84 bounding box, tiles which don't intersect it are skipped
+	Bound *orb.Bound
+}
+
+// NewZoomFilter creates a filter by single zoom level, or by all levels on AllZoomLevels
+func NewZoomFilter(zoom int) TileFilter {
+	if zoom == AllZoomLevels {
+		return TileFilter{}
+	}
+	return TileFilter{Zoom: &TileRange{Min: int64(zoom), Max: int64(zoom)}}
+}
+
+// TileIterator streams tiles one by one.
+// It isn't safe for concurrent use.
+type TileIterator struct {
+	ctx  context.Context
+	rows *sqlx.Rows
+	tile *Tile
+	err  error
+}
+
+// Tiles returns an iterator over tiles matching the filter.
+// The iterator must be closed after use.
+func (m *Manager) Tiles(ctx context.Context, filter TileFilter) (*TileIterator, error) {
+	where, args, err := m.filterCondition(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryxContext(ctx, `
+      SELECT "zoom_level", "tile_column", "tile_row", "tile_data"
+      FROM "tiles"
+      WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &TileIterator{ctx: ctx, rows: rows}, nil
+}
+
+// CountTiles matching the filter
+func (m *Manager) CountTiles(ctx context.Context, filter TileFilter) (int, error) {
+	where, args, err := m.filterCondition(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	return count, m.db.QueryRowxContext(ctx, `SELECT COUNT(*) FROM "tiles" WHERE `+where, args...).Scan(&count)
+}
+
+// Next tile, returns false if there are no more tiles or an error occurred
+func (it *TileIterator) Next() bool {
+	it.tile = nil
+	if it.err != nil {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		return false
+	}
+	var t Tile
+	if it.err = it.rows.Scan(&t.ZoomLevel, &t.Column, &t.Row, &t.Data); it.err != nil {
+		return false
+	}
+	it.tile = &t
+	return true
+}
+
+// Tile fetched by the last Next call
+func (it *TileIterator) Tile() *Tile {
+	return it.tile
+}
+
+// Err occurred during iteration
+func (it *TileIterator) Err() error {
+	return it.err
+}
+
+// Close the iterator and free database resources
+func (it *TileIterator) Close() error {
+	return it.rows.Close()
+}
+
+// filterCondition builds SQL WHERE condition and its arguments
+func (m *Manager) filterCondition(ctx context.Context, filter TileFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	for _, c := range []struct {
+		column string
+		r      *TileRange
+	}{
+		{"zoom_level", filter.Zoom},
+		{"tile_column", filter.Column},
+		{"tile_row", filter.Row},
+	} {
+		if c.r == nil {
+			continue
+		}
+		conditions = append(conditions, `"`+c.column+`" BETWEEN ? AND ?`)
+		args = append(args, c.r.Min, c.r.Max)
+	}
+
+	if filter.Bound != nil {
+		zoom := filter.Zoom
+		if zoom == nil {
+			var err error
+			if zoom, err = m.zoomRange(ctx); err != nil {
+				return "", nil, err
+			}
+		}
+		var boundConditions []string
+		for z := zoom.Min; z <= zoom.Max; z++ {
+			columns, rows := BoundTileRanges(*filter.Bound, z)
+			boundConditions = append(boundConditions,
+				`("zoom_level" = ? AND "tile_column" BETWEEN ? AND ? AND "tile_row" BETWEEN ? AND ?)`)
+			args = append(args, z, columns.Min, columns.Max, rows.Min, rows.Max)
+		}
+		if len(boundConditions) == 0 {
+			boundConditions = append(boundConditions, "0")
+		}
+		conditions = append(conditions, "("+strings.Join(boundConditions, " OR ")+")")
+	}
+
+	if len(conditions) == 0 {
+		return "1", nil, nil
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// zoomRange of stored tiles
+func (m *Manager) zoomRange(ctx context.Context) (*TileRange, error) {
+	var minZoom, maxZoom sql.NullInt64
+	row := m.db.QueryRowxContext(ctx, `SELECT MIN("zoom_level"), MAX("zoom_level") FROM "tiles"`)
+	if err := row.Scan(&minZoom, &maxZoom); err != nil {
+		return nil, err
+	}
+	if !minZoom.Valid {
+		// Empty tiles table; makes an empty range
+		return &TileRange{Min: 0, Max: -1}, nil
+	}
+	return &TileRange{Min: minZoom.Int64, Max: maxZoom.Int64}, nil
+}
+
+// BoundTileRanges returns column and TMS row ranges covering WGS 84 bound at zoom level
+func BoundTileRanges(bound orb.Bound, zoom int64) (columns TileRange, rows TileRange) {
+	z := maptile.Zoom(zoom)
+	maxIndex := int64(1)<<uint(zoom) - 1
+
+	// Top left and bottom right tiles in XYZ scheme
+	topLeft := maptile.Fraction(orb.Point{bound.Min.Lon(), bound.Max.Lat()}, z)
+	bottomRight := maptile.Fraction(orb.Point{bound.Max.Lon(), bound.Min.Lat()}, z)
+
+	columns.Min = clampTileIndex(topLeft.X(), maxIndex)
+	columns.Max = clampTileIndex(bottomRight.X(), maxIndex)
+	rows.Min = maxIndex - clampTileIndex(bottomRight.Y(), maxIndex)
+	rows.Max = maxIndex - clampTileIndex(topLeft.Y(), maxIndex)
+	return
+}
+
+// clampTileIndex from fraction into [0, maxIndex]
+func clampTileIndex(fraction float64, maxIndex int64) int64 {
+	index := int64(math.Floor(fraction))
+	if index < 0 {
+		return 0
+	}
+	if index > maxIndex {
+		return maxIndex
+	}
+	return index
+}
@@ -0,0 +1,78 @@
+package mbtiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/stretchr/testify/require"
+)
+
+const testTilesPath = "../../data/tiles-world-vector.mbtiles"
+
+func countTiles(t *testing.T, m *Manager, filter TileFilter) int {
+	it, err := m.Tiles(context.Background(), filter)
+	require.NoError(t, err)
+	defer it.Close()
+
+	count := 0
+	for it.Next() {
+		require.NotEmpty(t, it.Tile().Data)
+		count++
+	}
+	require.NoError(t, it.Err())
+	return count
+}
+
+func TestTilesFilter(t *testing.T) {
+	m, err := NewManager(testTilesPath)
+	require.NoError(t, err)
+
+	require.Equal(t, 985, countTiles(t, m, TileFilter{}))
+	require.Equal(t, 689, countTiles(t, m, NewZoomFilter(5)))
+	require.Equal(t, 21, countTiles(t, m, TileFilter{Zoom: &TileRange{Min: 0, Max: 2}}))
+	require.Equal(t, 1, countTiles(t, m, TileFilter{
+		Zoom:   &TileRange{Min: 1, Max: 1},
+		Column: &TileRange{Min: 0, Max: 0},
+		Row:    &TileRange{Min: 1, Max: 1},
+	}))
+
+	// Canary islands bound covers a single tile on low zoom levels
+	bound := orb.Bound{Min: orb.Point{-18.2, 27.6}, Max: orb.Point{-13.4, 29.5}}
+	require.Equal(t, 3, countTiles(t, m, TileFilter{Zoom: &TileRange{Min: 0, Max: 2}, Bound: &bound}))
+	require.Less(t, countTiles(t, m, TileFilter{Bound: &bound}), 20)
+}
+
+func TestBoundTileRanges(t *testing.T) {
+	world := orb.Bound{Min: orb.Point{-180, -85.06}, Max: orb.Point{180, 85.06}}
+	columns, rows := BoundTileRanges(world, 3)
+	require.Equal(t, TileRange{Min: 0, Max: 7}, columns)
+	require.Equal(t, TileRange{Min: 0, Max: 7}, rows)
+
+	// North-east quarter is on top rows in TMS scheme
+	columns, rows = BoundTileRanges(orb.Bound{Min: orb.Point{1, 1}, Max: orb.Point{179, 84}}, 1)
+	require.Equal(t, TileRange{Min: 1, Max: 1}, columns)
+	require.Equal(t, TileRange{Min: 1, Max: 1}, rows)
+}
+
+func TestWalkThroughAllZoomLevels(t *testing.T) {
+	m, err := NewManager(testTilesPath)
+	require.NoError(t, err)
+
+	zooms := map[int64]int{}
+	require.NoError(t, m.WalkThroughTiles(func(tile *Tile) bool {
+		zooms[tile.ZoomLevel]++
+		return true
+	}, AllZoomLevels))
+	require.Len(t, zooms, 6)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	walked := 0
+	err = m.WalkTiles(ctx, TileFilter{}, func(tile *Tile) bool {
+		walked++
+		cancel()
+		return true
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, walked)
+}
@@ -0,0 +1,58 @@
+package mbtiles
+
+import (
+	"github.com/paulmach/orb/geojson"
+)
+
+// LabelProperty of GeoJSON feature, place name in requested language
+const LabelProperty = "label"
+
+// languageKeys of name properties in OpenMapTiles schema, e.g. "name:es" and "name_es" of "es"
+func languageKeys(lang string) []string {
+	if lang == "" || lang == "name" {
+		return []string{"name"}
+	}
+	return []string{"name:" + lang, "name_" + lang}
+}
+
+// matchKeys of names, configured name keys followed by requested languages keys
+func (cfg SearchConfig) matchKeys() []string {
+	keys := append([]string{}, cfg.NameKeys...)
+	for _, lang := range cfg.Languages {
+		keys = append(keys, languageKeys(lang)...)
+	}
+	return uniqueStrings(keys)
+}
+
+// labelKeys of displayed name, requested languages keys followed by configured name keys
+func (cfg SearchConfig) labelKeys() []string {
+	var keys []string
+	for _, lang := range cfg.Languages {
+		keys = append(keys, languageKeys(lang)...)
+	}
+	return uniqueStrings(append(keys, cfg.NameKeys...))
+}
+
+// placeNames of all keys, duplicates are skipped
+func placeNames(properties geojson.Properties, keys []string) []string {
+	var names []string
+	for _, key := range keys {
+		if value, ok := properties[key].(string); ok && value != "" {
+			names = append(names, value)
+		}
+	}
+	return uniqueStrings(names)
+}
+
+// uniqueStrings in order of first occurrence
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+// testLanguageFeatures with names in several languages
+var testLanguageFeatures = []testFeature{
+	{layer: "place", zoom: 14, geometry: orb.Point{-16.6291, 28.2916}, properties: geojson.Properties{
+		"name": "Tenerife", "name:latin": "Tenerife", "name:de": "Teneriffa", "class": "island",
+	}},
+	{layer: "place", zoom: 14, geometry: orb.Point{-15.7000, 28.1000}, properties: geojson.Properties{
+		"name": "Canarias", "name:latin": "Canarias", "name:es": "Islas Canarias", "name_en": "Canary Islands", "class": "state",
+	}},
+}
+
+func TestSearchLanguages(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testLanguageFeatures))
+	require.NoError(t, err)
+
+	// English and German names of both key styles are matched by default
+	places, err := m.Search("canary", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Canarias", places[0].Name)
+	places, err = m.Search("teneriffa", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Tenerife", places[0].Name)
+
+	// Names of requested languages are matched and displayed by fallback chain
+	cfg := m.SearchConfig()
+	cfg.Languages = []string{"de", "es", "en"}
+	m.SetSearchConfig(cfg)
+	places, err = m.Search("teneriffa", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Teneriffa", places[0].Name)
+	places, err = m.Search("canary", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Islas Canarias", places[0].Name)
+
+	cfg.Languages = []string{"en", "es"}
+	m.SetSearchConfig(cfg)
+	places, err = m.Suggest("islas", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Canary Islands", places[0].Name)
+
+	// Unknown languages fall back to name keys
+	cfg.Languages = []string{"fr"}
+	m.SetSearchConfig(cfg)
+	places, err = m.Search("teneriffa", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Tenerife", places[0].Name)
+
+	// Suggestions are indexed by the config they were requested with
+	cfg.Layers = []string{"poi"}
+	m.SetSearchConfig(cfg)
+	suggestions := &suggestIndex{cfg: DefaultSearchConfig()}
+	require.NoError(t, suggestions.load(m))
+	require.NotEmpty(t, suggestions.entries)
+}
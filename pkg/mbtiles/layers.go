@@ -0,0 +1,77 @@
+package mbtiles
+
+import (
+	"context"
+	"sort"
+
+	"github.com/paulmach/orb/encoding/mvt"
+)
+
+// Field types of vector layer attributes
+const (
+	StringField  = "String"
+	NumberField  = "Number"
+	BooleanField = "Boolean"
+	MixedField   = "Mixed"
+)
+
+// ScanVectorLayers by decoding tiles matching the filter.
+// Layers are sorted by ID, zoom range is the one the layer appears at.
+// Fields having values of different types are of MixedField type.
+func (m *Manager) ScanVectorLayers(ctx context.Context, filter TileFilter) ([]VectorLayer, error) {
+	layers := map[string]*VectorLayer{}
+	err := m.WalkTileLayers(ctx, filter, func(tile *Tile, layer *mvt.Layer) bool {
+		zoom := int(tile.ZoomLevel)
+		vectorLayer, ok := layers[layer.Name]
+		if !ok {
+			vectorLayer = &VectorLayer{ID: layer.Name, MinZoom: zoom, MaxZoom: zoom, Fields: map[string]string{}}
+			layers[layer.Name] = vectorLayer
+		}
+		if zoom < vectorLayer.MinZoom {
+			vectorLayer.MinZoom = zoom
+		}
+		if zoom > vectorLayer.MaxZoom {
+			vectorLayer.MaxZoom = zoom
+		}
+
+		for _, feature := range layer.Features {
+			for key, value := range feature.Properties {
+				fieldType := valueType(value)
+				if fieldType == "" {
+					continue
+				}
+				if known, ok := vectorLayer.Fields[key]; ok && known != fieldType {
+					fieldType = MixedField
+				}
+				vectorLayer.Fields[key] = fieldType
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]VectorLayer, 0, len(layers))
+	for _, layer := range layers {
+		result = append(result, *layer)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// valueType of decoded vector tile value, empty for unsupported values
+func valueType(value interface{}) string {
+	if _, ok := numberValue(value); ok {
+		return NumberField
+	}
+	switch value.(type) {
+	case string:
+		return StringField
+	case bool:
+		return BooleanField
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package mbtiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanVectorLayers(t *testing.T) {
+	features := append([]testFeature{
+		{layer: "poi", zoom: 12, geometry: orb.Point{-16.2518, 28.4636}, properties: geojson.Properties{
+			"name": "Farmacia", "class": "pharmacy", "rank": 1.0, "indoor": true,
+		}},
+		{layer: "poi", zoom: 13, geometry: orb.Point{-16.2518, 28.4636}, properties: geojson.Properties{
+			"name": "Bar", "class": "bar", "rank": "high",
+		}},
+	}, testPlaceFeatures...)
+	manager, err := NewManager(writeTestTiles(t, features))
+	require.NoError(t, err)
+
+	layers, err := manager.ScanVectorLayers(context.Background(), TileFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []VectorLayer{
+		{ID: "place", MinZoom: 14, MaxZoom: 14, Fields: map[string]string{
+			"name": StringField, "name:latin": StringField, "class": StringField, "rank": NumberField,
+		}},
+		{ID: "poi", MinZoom: 12, MaxZoom: 13, Fields: map[string]string{
+			"name": StringField, "class": StringField, "rank": MixedField, "indoor": BooleanField,
+		}},
+	}, layers)
+}
@@ -1,22 +1,38 @@
 package mbtiles
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"sync"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/paulmach/orb/encoding/mvt"
 	"github.com/paulmach/orb/geojson"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/text/language"
-	"golang.org/x/text/search"
 )
 
 type Manager struct {
 	db *sqlx.DB
+
+	// Guards search config and indexes built for it, shared by copies of the manager
+	mu *sync.RWMutex
+
+	// Place index, nil if it isn't built
+	index *PlaceIndex
+
+	// Searchable layers and properties
+	searchConfig SearchConfig
+
+	// Prefix index of place names, loaded by the first suggestion
+	suggestions *suggestIndex
+
+	// Administrative units, loaded by the first search
+	contexts *contextIndex
 }
 
 func NewManager(path string) (*Manager, error) {
@@ -58,9 +74,36 @@ func NewManager(path string) (*Manager, error) {
 		return nil, err
 	}
 	m := Manager{
-		db: db,
+		db:           db,
+		mu:           &sync.RWMutex{},
+		searchConfig: DefaultSearchConfig(),
 	}
-	return &m, err
+	m.suggestions = &suggestIndex{cfg: m.searchConfig}
+	m.contexts = &contextIndex{cfg: m.searchConfig}
+
+	// Use place index if it's built for the current file
+	indexPath := IndexPath(path)
+	if index, err := OpenPlaceIndex(indexPath); err == nil {
+		if isPlaceIndexStale(path, indexPath) {
+			logrus.
+				WithField("path", indexPath).
+				Warn("Place index is older than mbtiles file and isn't used, rebuild it")
+			_ = index.Close()
+		} else {
+			m.index = index
+		}
+	} else if errors.Is(err, ErrPlaceIndexVersion) {
+		logrus.
+			WithField("path", indexPath).
+			Warn("Place index of other version isn't used, rebuild it")
+	}
+	return &m, nil
+}
+
+// Close database and place index
+func (m *Manager) Close() error {
+	m.setPlaceIndex(nil)
+	return m.db.Close()
 }
 
 // GetTile data only a pbf image
@@ -91,76 +134,53 @@ func (m *Manager) GetTile(z int64, x int64, y int64) ([]byte, error) {
 	return tileData, nil
 }
 
-// GetTiles list
+// GetTiles list.
+// All tiles are loaded into memory, use Tiles to stream huge files.
 func (m *Manager) GetTiles() ([]*Tile, error) {
-	// Fetch tiles
-	rows, err := m.db.Queryx("SELECT zoom_level, tile_column, tile_row FROM tiles")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	// sqlx.Next and sqlx.StructScan are not safe for concurrent use.
-	// If you throw together a simple unit test for your code and run it
-	// with the race detector go test -race, it will report a race condition
-	// on an unexported field of the "database/sql".Rows struct:
 	var tiles []*Tile
-	for rows.Next() {
-		var t Tile
-		if err := rows.StructScan(&t); err != nil {
-			logrus.WithError(err).
-				Error("Fetch tile from db")
-		}
-		t.Data, err = m.GetTile(t.ZoomLevel, t.Column, t.Row)
-		tiles = append(tiles, &t)
-		if err != nil {
-			logrus.WithField("tile", t).
-				Warn("Get tile data")
-		}
-	}
-
-	return tiles, nil
+	return tiles, m.WalkTiles(context.Background(), TileFilter{}, func(tile *Tile) bool {
+		tiles = append(tiles, tile)
+		return true
+	})
 }
 
-// WalkThroughTiles and call back by each tile
-func (m *Manager) WalkThroughTiles(callback func(tile *Tile) bool, zoom int) error {
-	// Fetch tiles
-	rows, err := m.db.Queryx(fmt.Sprintf("SELECT zoom_level, tile_column, tile_row FROM tiles WHERE zoom_level = %d", zoom))
+// WalkTiles matching the filter and call back by each tile until callback returns false
+func (m *Manager) WalkTiles(ctx context.Context, filter TileFilter, callback func(tile *Tile) bool) error {
+	it, err := m.Tiles(ctx, filter)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	defer func(it *TileIterator) {
+		_ = it.Close()
+	}(it)
 
-	// sqlx.Next and sqlx.StructScan are not safe for concurrent use.
-	// If you throw together a simple unit test for your code and run it
-	// with the race detector go test -race, it will report a race condition
-	// on an unexported field of the "database/sql".Rows struct:
-	for rows.Next() {
-		var t Tile
-		if err := rows.StructScan(&t); err != nil {
-			logrus.
-				WithError(err).
-				Error("Fetch tile from db")
-		}
-		t.Data, err = m.GetTile(t.ZoomLevel, t.Column, t.Row)
-		if err != nil {
-			logrus.
-				WithField("tile", t).
-				Warn("Get tile data")
-			continue
-		}
-		continueWalk := callback(&t)
-		t.Data = nil
-		if !continueWalk {
+	for it.Next() {
+		if !callback(it.Tile()) {
 			return nil
 		}
 	}
-	return nil
+	return it.Err()
+}
+
+// WalkThroughTiles of zoom level, or of AllZoomLevels, and call back by each tile
+func (m *Manager) WalkThroughTiles(callback func(tile *Tile) bool, zoom int) error {
+	return m.WalkTiles(context.Background(), NewZoomFilter(zoom), func(tile *Tile) bool {
+		continueWalk := callback(tile)
+		tile.Data = nil
+		return continueWalk
+	})
 }
 
 // WalkThroughLayers and decode tile by the way
 func (m *Manager) WalkThroughLayers(callback func(layer *mvt.Layer) bool, zoomLevel int) error {
-	return m.WalkThroughTiles(func(tile *Tile) bool {
+	return m.WalkTileLayers(context.Background(), NewZoomFilter(zoomLevel), func(tile *Tile, layer *mvt.Layer) bool {
+		return callback(layer)
+	})
+}
+
+// WalkTileLayers matching the filter and call back by each decoded layer with its tile
+func (m *Manager) WalkTileLayers(ctx context.Context, filter TileFilter, callback func(tile *Tile, layer *mvt.Layer) bool) error {
+	return m.WalkTiles(ctx, filter, func(tile *Tile) bool {
 		pbf, err := tile.GetProtobuf()
 		if err != nil {
 			logrus.WithField("tile", tile).Warn("can't read tile proto buff")
@@ -173,55 +193,11 @@ func (m *Manager) WalkThroughLayers(callback func(layer *mvt.Layer) bool, zoomLe
 		}
 
 		for _, layer := range layers {
-			if !callback(layer) {
-				return false
-			}
-		}
-		return true
-	}, zoomLevel)
-}
-
-// WalkThroughPlaces and call callback if something was found
-func (m *Manager) WalkThroughPlaces(callback func(subj string, cls string, feature *geojson.Feature) bool) error {
-	return m.WalkThroughLayers(func(layer *mvt.Layer) bool {
-		if layer.Name != "place" {
-			return true
-		}
-		for _, feature := range layer.Features {
-			name, ok := feature.Properties["name:latin"].(string)
-			if !ok {
-				continue
-			}
-			cls, ok := feature.Properties["class"].(string)
-			if !ok {
-				continue
-			}
-			if !callback(name, cls, feature) {
+			if !callback(tile, layer) {
 				return false
 			}
 		}
 		return true
-	}, 14)
-}
-
-// Search features where subject has a query
-func (m *Manager) Search(query string, maxResults int) ([]*geojson.Feature, error) {
-	var features []*geojson.Feature
-	options := []search.Option{
-		// Loose causes case, diacritics and width to be ignored.
-		search.Loose,
-		// IgnoreDiacritics causes diacritics to be ignored ("ö" == "o").
-		search.IgnoreDiacritics,
-		// IgnoreWidth equates narrow with wide variants.
-		search.IgnoreWidth,
-	}
-	sp := search.New(language.Und, options...).CompileString(query)
-	return features, m.WalkThroughPlaces(func(subj string, cls string, feature *geojson.Feature) bool {
-		start, end := sp.IndexString(subj)
-		if start > -1 && end > 0 {
-			features = append(features, feature)
-		}
-		return len(features) < maxResults
 	})
 }
 
@@ -1,5 +1,17 @@
 package mbtiles
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+)
+
 // Meta of data.
 // The metadata table MAY contain additional rows for tile sets that implement UTFGrid-based interaction or for other purposes.
 // see: https://github.com/mapbox/mbtiles-spec/blob/master/1.3/spec.md
@@ -32,6 +44,9 @@ type Meta struct {
 	// The highest zoom level for which the tileset provides data
 	MaxZoom int `json:"maxzoom,omitempty"`
 
+	// Zoom level from which clients may generate tiles missing in the tile set by overzooming
+	FillZoom int `json:"fillzoom,omitempty"`
+
 	// The longitude, latitude, and zoom level of the default view of the map.
 	// Example: -122.1906,37.7599,11
 	Center []float64 `json:"center,omitempty" mapstructure:"-"`
@@ -52,7 +67,7 @@ type Meta struct {
 	// PBF's URL template. Example: ["http://localhost/tiles/{z}/{x}/{y}.pbf"]
 	Tiles []string `json:"tiles,omitempty"`
 
-	// ???
+	// TileJSON version of the index file the tile set is packed from
 	TileJson string `json:"tile-json,omitempty"`
 
 	// Example: "xyz"
@@ -65,6 +80,9 @@ type Meta struct {
 
 	//  An attribution string, which explains in English (and HTML) the sources of data and/or style for the map.
 	Attribution string `json:"attribution,omitempty"`
+
+	// Statistics of layers, their geometries and attributes of json row
+	TileStats *TileStats `json:"tilestats,omitempty" mapstructure:"-"`
 }
 
 // VectorLayer for style
@@ -80,3 +98,149 @@ type VectorLayer struct {
 
 	Fields map[string]string `json:"fields,omitempty"`
 }
+
+// TileStats of vector tile set in mapbox-geostats schema
+// see: https://github.com/mapbox/mapbox-geostats
+type TileStats struct {
+	LayerCount int              `json:"layerCount"`
+	Layers     []TileStatsLayer `json:"layers"`
+}
+
+// TileStatsLayer of features of one geometry type
+type TileStatsLayer struct {
+	Layer          string               `json:"layer"`
+	Count          int                  `json:"count"`
+	Geometry       string               `json:"geometry"`
+	AttributeCount int                  `json:"attributeCount"`
+	Attributes     []TileStatsAttribute `json:"attributes"`
+}
+
+// TileStatsAttribute values of a layer
+type TileStatsAttribute struct {
+	Attribute string        `json:"attribute"`
+	Count     int           `json:"count"`
+	Type      string        `json:"type"`
+	Values    []interface{} `json:"values"`
+	Min       *float64      `json:"min,omitempty"`
+	Max       *float64      `json:"max,omitempty"`
+}
+
+// GetMeta data from database file.
+// Format is detected by tile data, the json row is optional.
+func (m *Manager) GetMeta() (*Meta, error) {
+	rows, err := m.db.Queryx("SELECT name,value FROM metadata")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metaMap := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		metaMap[k] = v
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	meta := &Meta{
+		Scheme:   "xyz",
+		Type:     "baselayer",
+		Format:   "pbf",
+		Basename: "base",
+		Profile:  "mercator",
+		Scale:    1,
+		JSON:     metaMap["json"],
+	}
+	if meta.Bounds, err = parseBounds(metaMap["bounds"]); err != nil {
+		logrus.WithField("bounds", metaMap["bounds"]).WithError(err).Warn("Skip invalid metadata")
+	}
+	if meta.Center, err = parseCenter(metaMap["center"]); err != nil {
+		logrus.WithField("center", metaMap["center"]).WithError(err).Warn("Skip invalid metadata")
+	}
+
+	if meta.JSON != "" {
+		if err := json.Unmarshal([]byte(meta.JSON), meta); err != nil {
+			return nil, err
+		}
+	}
+	if err := mapstructure.WeakDecode(&metaMap, meta); err != nil {
+		return nil, err
+	}
+
+	// Stored format may be missing or wrong
+	if info, err := m.detectTileInfo(); err == nil {
+		meta.Format = info.Extension()
+	}
+	return meta, nil
+}
+
+// detectTileInfo of any stored tile
+func (m *Manager) detectTileInfo() (TileInfo, error) {
+	var data []byte
+	if err := m.db.Get(&data, `SELECT "tile_data" FROM "tiles" WHERE length("tile_data") > 0 LIMIT 1`); err != nil {
+		return TileInfo{}, err
+	}
+	return DetectTile(data)
+}
+
+// parseBounds of "left,bottom,right,top" WGS 84 degrees, nil if value is empty
+func parseBounds(value string) ([]float64, error) {
+	bounds, err := parseFloats(value)
+	if err != nil || bounds == nil {
+		return nil, err
+	}
+	if len(bounds) != 4 {
+		return nil, fmt.Errorf("bounds have %d of 4 values", len(bounds))
+	}
+	for i := 0; i < 4; i += 2 {
+		if bounds[i] < -180 || bounds[i] > 180 || bounds[i+1] < -90 || bounds[i+1] > 90 {
+			return nil, errors.New("bounds are out of WGS 84 range")
+		}
+	}
+	if bounds[0] > bounds[2] || bounds[1] > bounds[3] {
+		return nil, errors.New("bounds minimum is greater than maximum")
+	}
+	return bounds, nil
+}
+
+// parseCenter of "lon,lat[,zoom]", nil if value is empty
+func parseCenter(value string) ([]float64, error) {
+	center, err := parseFloats(value)
+	if err != nil || center == nil {
+		return nil, err
+	}
+	if len(center) != 2 && len(center) != 3 {
+		return nil, fmt.Errorf("center has %d of 3 values", len(center))
+	}
+	if center[0] < -180 || center[0] > 180 || center[1] < -90 || center[1] > 90 {
+		return nil, errors.New("center is out of WGS 84 range")
+	}
+	if len(center) == 3 && (center[2] < 0 || center[2] > 30 || center[2] != math.Trunc(center[2])) {
+		return nil, errors.New("center zoom isn't a zoom level")
+	}
+	return center, nil
+}
+
+// parseFloats of comma separated value, nil if value is empty
+func parseFloats(value string) ([]float64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	floats := make([]float64, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%q isn't a finite number", part)
+		}
+		floats[i] = f
+	}
+	return floats, nil
+}
@@ -0,0 +1,186 @@
+package mbtiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"github.com/sirupsen/logrus"
+)
+
+// tileFilePattern matches {z}/{x}/{y}.{ext} relative tile path
+var tileFilePattern = regexp.MustCompile(`^(\d+)/(\d+)/(\d+)\.([A-Za-z0-9]+)$`)
+
+// PackerSettings groups all cfg for NewPacker
+type PackerSettings struct {
+	// Tiles directory path with {z}/{x}/{y}.{ext} tree and optional index.json TileJSON
+	Path string
+
+	// Gzip raw PBF tiles as MBTiles spec requires
+	Compress bool
+
+	// Number of tiles inserted in one transaction
+	BatchSize int
+}
+
+// Packer of tiles directory into MBTiles file
+type Packer struct {
+	Writer
+
+	cfg PackerSettings
+
+	TilesCount int
+}
+
+// NewPacker creates a new packer into MBTiles file
+func NewPacker(exportPath string, settings PackerSettings) (*Packer, error) {
+	writer, err := NewWriter(exportPath, WriterSettings{BatchSize: settings.BatchSize})
+	if err != nil {
+		return nil, err
+	}
+	return &Packer{
+		Writer: *writer,
+		cfg:    settings,
+	}, nil
+}
+
+// Pack tiles and metadata
+func (p *Packer) Pack() error {
+	var format string
+	var bound *orb.Bound
+	zoom := TileRange{Min: -1, Max: -1}
+
+	err := filepath.WalkDir(p.cfg.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(p.cfg.Path, path)
+		if err != nil {
+			return err
+		}
+		match := tileFilePattern.FindStringSubmatch(filepath.ToSlash(relPath))
+		if match == nil {
+			logrus.
+				WithField("path", path).
+				Debug("Skip non tile file")
+			return nil
+		}
+		z, _ := strconv.ParseInt(match[1], 10, 64)
+		x, _ := strconv.ParseInt(match[2], 10, 64)
+		y, _ := strconv.ParseInt(match[3], 10, 64)
+		ext := match[4]
+		if z > 30 || x >= int64(1)<<uint(z) || y >= int64(1)<<uint(z) {
+			logrus.
+				WithField("path", path).
+				Warn("Skip tile out of zoom level range")
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if info, _ := DetectTile(data); p.cfg.Compress && ext == "pbf" && info.Encoding == IDENTITY {
+			if data, err = gzipData(data); err != nil {
+				return err
+			}
+		}
+
+		// Stored rows are in TMS scheme
+		if err := p.WriteTile(z, x, FlipRow(z, y), data); err != nil {
+			return err
+		}
+		p.TilesCount++
+
+		// Collect metadata of packed tiles
+		if format == "" {
+			format = ext
+		}
+		if zoom.Min < 0 || z < zoom.Min {
+			zoom.Min = z
+		}
+		if z > zoom.Max {
+			zoom.Max = z
+		}
+		tileBound := maptile.New(uint32(x), uint32(y), maptile.Zoom(z)).Bound()
+		if bound == nil {
+			bound = &tileBound
+		} else {
+			*bound = bound.Union(tileBound)
+		}
+
+		logrus.
+			WithField("from", p.TilesCount).
+			WithField("path", path).
+			Info("Pack tile file")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+
+	// Get meta
+	meta, err := p.readIndex()
+	if err != nil {
+		return err
+	}
+	if meta.Name == "" {
+		meta.Name = filepath.Base(p.cfg.Path)
+	}
+	if format != "" {
+		meta.Format = format
+	}
+	if p.TilesCount > 0 {
+		meta.MinZoom = int(zoom.Min)
+		meta.MaxZoom = int(zoom.Max)
+		if len(meta.Bounds) != 4 {
+			meta.Bounds = []float64{bound.Min.Lon(), bound.Min.Lat(), bound.Max.Lon(), bound.Max.Lat()}
+		}
+		if len(meta.Center) != 3 {
+			center := bound.Center()
+			meta.Center = []float64{center.Lon(), center.Lat(), float64(zoom.Min)}
+		}
+	}
+	return p.WriteMeta(meta)
+}
+
+// readIndex TileJSON file if exists
+func (p *Packer) readIndex() (*Meta, error) {
+	meta := &Meta{}
+	indexPath := filepath.Join(p.cfg.Path, "index.json")
+	indexJson, err := os.ReadFile(indexPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logrus.
+			WithField("path", indexPath).
+			Warn("Index file not found")
+		return meta, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return meta, json.Unmarshal(indexJson, meta)
+}
+
+// gzipData compresses tile data
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package mbtiles
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackExported(t *testing.T) {
+	tilesPath := t.TempDir()
+	ex, err := NewExporter(testTilesPath, ExporterSettings{
+		Path:       tilesPath,
+		Decompress: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, ex.Export())
+
+	packedPath := filepath.Join(t.TempDir(), "packed.mbtiles")
+	packer, err := NewPacker(packedPath, PackerSettings{
+		Path:      tilesPath,
+		Compress:  true,
+		BatchSize: 100,
+	})
+	require.NoError(t, err)
+	require.NoError(t, packer.Pack())
+	require.NoError(t, packer.Close())
+	require.Equal(t, ex.TilesCount, packer.TilesCount)
+
+	packed, err := NewManager(packedPath)
+	require.NoError(t, err)
+	count, err := packed.CountTiles(context.Background(), NewZoomFilter(5))
+	require.NoError(t, err)
+	require.Equal(t, 689, count)
+
+	// Tiles are stored in the same TMS rows
+	original := &Tile{ZoomLevel: 3, Column: 4, Row: 5}
+	original.Data, err = ex.GetTile(original.ZoomLevel, original.Column, original.Row)
+	require.NoError(t, err)
+	repacked := &Tile{ZoomLevel: 3, Column: 4, Row: 5}
+	repacked.Data, err = packed.GetTile(repacked.ZoomLevel, repacked.Column, repacked.Row)
+	require.NoError(t, err)
+	originalPbf, err := original.GetProtobuf()
+	require.NoError(t, err)
+	repackedPbf, err := repacked.GetProtobuf()
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(originalPbf, repackedPbf))
+
+	meta, err := packed.GetMeta()
+	require.NoError(t, err)
+	require.Equal(t, "tiles-world-vector.mbtiles", meta.Name)
+	require.Equal(t, "pbf", meta.Format)
+	require.Equal(t, 5, meta.MaxZoom)
+	require.Len(t, meta.Bounds, 4)
+	require.Len(t, meta.VectorLayers, 1)
+}
+
+func TestWriterRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollback.mbtiles")
+	writer, err := NewWriter(path, WriterSettings{BatchSize: 10})
+	require.NoError(t, err)
+	_, err = writer.db.Exec(`CREATE TRIGGER "reject" BEFORE INSERT ON "tiles" WHEN NEW."zoom_level" > 20
+      BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	require.NoError(t, err)
+
+	// Batch is discarded on the failed insert
+	require.NoError(t, writer.WriteTile(1, 0, 0, []byte{1}))
+	require.Error(t, writer.WriteTile(21, 0, 0, []byte{1}))
+	require.NoError(t, writer.WriteTile(1, 1, 0, []byte{1}))
+	require.NoError(t, writer.Close())
+
+	manager, err := NewManager(path)
+	require.NoError(t, err)
+	data, err := manager.GetTile(1, 0, 0)
+	require.NoError(t, err)
+	require.Empty(t, data)
+	data, err = manager.GetTile(1, 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, data)
+}
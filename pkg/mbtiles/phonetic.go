@@ -0,0 +1,80 @@
+package mbtiles
+
+import (
+	"strings"
+)
+
+// phoneticMatchFactor of scores, spelled by ear names are ranked below exact ones
+const phoneticMatchFactor = 0.9
+
+// phoneticRules of Spanish pronunciation replaced in order in lower case names,
+// vowels of English spelling by ear are included.
+// "ch" and "sh" sound is kept as "ʧ" letter, which isn't produced by other rules.
+var phoneticRules = strings.NewReplacer(
+	"ch", "ʧ",
+	"ph", "f",
+	"sh", "ʧ",
+	"ll", "y",
+	"qu", "k",
+	"gue", "ge",
+	"gui", "gi",
+	"ge", "je",
+	"gi", "ji",
+	"ce", "se",
+	"ci", "si",
+	"oo", "u",
+	"ee", "i",
+	"ou", "u",
+	"h", "",
+	"c", "k",
+	"q", "k",
+	"z", "s",
+	"v", "b",
+	"w", "b",
+	"x", "ks",
+)
+
+// phoneticMatcher of names spelled by ear
+type phoneticMatcher struct {
+	// Collation matcher, exact matches are always found
+	exact *matcher
+
+	// Matcher of query phonetic key
+	phonetic *matcher
+}
+
+// newPhoneticMatcher of query
+func newPhoneticMatcher(query string) *phoneticMatcher {
+	return &phoneticMatcher{
+		exact:    newMatcher(query),
+		phonetic: newMatcher(phoneticKey(query)),
+	}
+}
+
+// match name exactly or by its phonetic key
+func (pm *phoneticMatcher) match(name string) (float64, bool) {
+	if score, ok := pm.exact.match(name); ok {
+		return score, true
+	}
+	if pm.phonetic.empty {
+		return 0, false
+	}
+	score, ok := pm.phonetic.match(phoneticKey(name))
+	return score * phoneticMatchFactor, ok
+}
+
+// phoneticKey of name words by Spanish pronunciation, e.g. "Lagoona" and "Laguna" are "laguna"
+func phoneticKey(name string) string {
+	words := strings.Fields(normalizeName(name))
+	for i, word := range words {
+		// Double letters sound as single ones, e.g. "rr"
+		var key []rune
+		for _, r := range phoneticRules.Replace(word) {
+			if len(key) == 0 || key[len(key)-1] != r {
+				key = append(key, r)
+			}
+		}
+		words[i] = string(key)
+	}
+	return strings.Join(words, " ")
+}
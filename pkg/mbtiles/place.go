@@ -1,16 +1,156 @@
 package mbtiles
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
 	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
 )
 
+// AddressProperty of GeoJSON feature, the Address of house number or street
+const AddressProperty = "address"
+
+// mergeDistance in meters between copies of the same place from neighbouring tiles
+const mergeDistance = 100
+
+// Place found by geocoder
 type Place struct {
-	ID         interface{}
-	Type       string
-	Geometry   *orb.Geometry
-	Properties *geojson.Properties
+	// Feature ID of vector tile
+	ID interface{} `json:"id,omitempty"`
+
+	// Name in requested language
+	Name string `json:"name"`
+
+	// Latin name
+	NameLatin string `json:"name:latin,omitempty" mapstructure:"name:latin"`
+
+	// Class of configured class keys
+	Class string `json:"class,omitempty" mapstructure:"class"`
+
+	// Centroid in WGS 84
+	Center orb.Point `json:"center"`
+
+	// Bounding box in WGS 84
+	Bound orb.Bound `json:"bbox"`
+
+	// Source layer and tile
+	Layer string       `json:"layer"`
+	Tile  maptile.Tile `json:"tile"`
+
+	// Relevance of search, the higher the more relevant
+	Score float64 `json:"score"`
+
+	// Distance in meters to reverse geocoded or focus point
+	Distance *float64 `json:"distance,omitempty"`
+
+	// Address of house number or street
+	Address *Address `json:"address,omitempty"`
+
+	// Administrative hierarchy from the place itself to its country
+	Context []ContextItem `json:"context,omitempty"`
+
+	// Name with its context, e.g. "Santa Úrsula, Tenerife, Santa Cruz de Tenerife, España"
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Geometry in WGS 84 and properties of source feature
+	Geometry   orb.Geometry       `json:"-"`
+	Properties geojson.Properties `json:"properties"`
+}
+
+// Places list of geocoder results
+type Places []*Place
+
+// newPlace of vector tile feature projected to WGS 84
+func (cfg SearchConfig) newPlace(feature *geojson.Feature, layer string, tile maptile.Tile) *Place {
+	bound := feature.Geometry.Bound()
+	return &Place{
+		ID:         feature.ID,
+		Name:       firstStringProperty(feature.Properties, cfg.labelKeys()),
+		NameLatin:  feature.Properties.MustString("name:latin", ""),
+		Class:      firstStringProperty(feature.Properties, cfg.ClassKeys),
+		Center:     bound.Center(),
+		Bound:      bound,
+		Layer:      layer,
+		Tile:       tile,
+		Geometry:   feature.Geometry,
+		Properties: feature.Properties,
+	}
+}
+
+// Feature of GeoJSON with source properties, layer, label, score and distance
+func (p *Place) Feature() *geojson.Feature {
+	feature := geojson.NewFeature(p.Geometry)
+	feature.ID = p.ID
+	feature.Properties = p.Properties.Clone()
+	feature.Properties["layer"] = p.Layer
+	feature.Properties[LabelProperty] = p.Name
+	feature.Properties[ScoreProperty] = p.Score
+	if p.Distance != nil {
+		feature.Properties[DistanceProperty] = *p.Distance
+	}
+	if p.Address != nil {
+		feature.Properties[AddressProperty] = p.Address
+	}
+	if p.Context != nil {
+		feature.Properties[ContextProperty] = p.Context
+		feature.Properties[DisplayNameProperty] = p.DisplayName
+	}
+	return feature
+}
+
+// FeatureCollection of GeoJSON
+func (places Places) FeatureCollection() *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, place := range places {
+		fc.Append(place.Feature())
+	}
+	return fc
+}
+
+// sortByScore the most relevant first
+func (places Places) sortByScore() {
+	sort.SliceStable(places, func(i, j int) bool {
+		return places[i].Score > places[j].Score
+	})
+}
+
+// placeSet of found places to merge copies of the same place from neighbouring tiles
+type placeSet map[string][]placeCopy
+
+// placeCopy kept in the set with the point identifying it
+type placeCopy struct {
+	at    orb.Point
+	place *Place
+}
+
+// add the place, see addAt
+func (s placeSet) add(place *Place) (*Place, bool) {
+	return s.addAt(place, place.Center)
+}
 
-	Class     string `json:"class" mapstructure:"class"`
-	NameLatin string `json:"name:latin" mapstructure:"name:latin"`
+// addAt point identifying the copy of the place, e.g. the point contained by clipped polygons.
+// Returns the kept copy and false if the place is already in the set.
+// A copy with smaller Distance replaces the content of the kept one, which is returned with true then,
+// so the place is new only if it's returned itself.
+// Places without ID and name can't be told apart and aren't merged.
+func (s placeSet) addAt(place *Place, at orb.Point) (*Place, bool) {
+	if place.ID == nil && place.Name == "" {
+		return place, true
+	}
+	key := fmt.Sprintf("%v\x00%s\x00%s\x00%s", place.ID, place.Layer, place.Name, place.Class)
+	for _, kept := range s[key] {
+		if geo.Distance(kept.at, at) >= mergeDistance {
+			continue
+		}
+		if place.Distance == nil || (kept.place.Distance != nil && *kept.place.Distance <= *place.Distance) {
+			return kept.place, false
+		}
+		*kept.place = *place
+		return kept.place, true
+	}
+	s[key] = append(s[key], placeCopy{at: at, place: place})
+	return place, true
 }
@@ -0,0 +1,79 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaces(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+
+	places, err := m.Search("santa cruz", 1)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	place := places[0]
+	require.Equal(t, "Santa Cruz de Tenerife", place.Name)
+	require.Equal(t, "Santa Cruz de Tenerife", place.NameLatin)
+	require.Equal(t, "city", place.Class)
+	require.Equal(t, "place", place.Layer)
+	require.Equal(t, maptile.At(orb.Point{-16.2518, 28.4636}, 14), place.Tile)
+	require.InDelta(t, -16.2518, place.Center.Lon(), 0.0001)
+	require.True(t, place.Bound.Contains(place.Center))
+	require.Greater(t, place.Score, 0.0)
+
+	// GeoJSON keeps source properties
+	fc := places.FeatureCollection()
+	require.Len(t, fc.Features, 1)
+	require.Equal(t, place.Center, fc.Features[0].Point())
+	require.Equal(t, "city", fc.Features[0].Properties["class"])
+	require.Equal(t, "place", fc.Features[0].Properties["layer"])
+	require.Equal(t, place.Score, fc.Features[0].Properties[ScoreProperty])
+	require.NotContains(t, place.Properties, ScoreProperty)
+}
+
+func TestPlaceSet(t *testing.T) {
+	newPlace := func(lon float64, distance float64) *Place {
+		return &Place{ID: 1, Layer: "place", Center: orb.Point{lon, 28.4}, Distance: &distance}
+	}
+	found := placeSet{}
+	far := newPlace(-16.25, 80)
+	kept, ok := found.add(far)
+	require.True(t, ok)
+	require.Same(t, far, kept)
+
+	// Nearer copy replaces the kept one
+	kept, ok = found.add(newPlace(-16.2501, 20))
+	require.True(t, ok)
+	require.Same(t, far, kept)
+	require.Equal(t, 20.0, *far.Distance)
+	require.Equal(t, -16.2501, far.Center.Lon())
+
+	// Farther copy doesn't
+	kept, ok = found.add(newPlace(-16.2502, 50))
+	require.False(t, ok)
+	require.Same(t, far, kept)
+	require.Equal(t, 20.0, *far.Distance)
+
+	// Distant place of the same name is another one
+	other := newPlace(-16.0, 10)
+	kept, ok = found.add(other)
+	require.True(t, ok)
+	require.Same(t, other, kept)
+
+	// Places are keyed by their configured name and class, ones without ID and name aren't merged
+	named := &Place{Layer: "poi", Name: "Farmacia", Class: "pharmacy", Center: orb.Point{-16.25, 28.4}}
+	_, ok = found.add(named)
+	require.True(t, ok)
+	_, ok = found.add(&Place{Layer: "poi", Name: "Farmacia", Class: "pharmacy", Center: orb.Point{-16.25, 28.4}})
+	require.False(t, ok)
+	_, ok = found.add(&Place{Layer: "poi", Name: "Farmacia", Class: "hospital", Center: orb.Point{-16.25, 28.4}})
+	require.True(t, ok)
+	for i := 0; i < 2; i++ {
+		_, ok = found.add(&Place{Layer: "landcover", Class: "grass", Center: orb.Point{-16.25, 28.4}})
+		require.True(t, ok)
+	}
+}
@@ -0,0 +1,348 @@
+package mbtiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/sirupsen/logrus"
+)
+
+// placeIndexVersion of the schema, indexes of other versions have to be rebuilt
+const placeIndexVersion = 3
+
+// placeIndexSchema of FTS5 sidecar database.
+// Names are normalized and split into trigrams, so that any substring of them is found as by scanning tiles.
+// Requires go-sqlite3 built with `fts5` tag.
+var placeIndexSchema = []string{
+	`DROP TABLE IF EXISTS "places"`,
+	`CREATE VIRTUAL TABLE "places" USING fts5(
+      "name" UNINDEXED,
+      "names",
+      "class" UNINDEXED,
+      "lon" UNINDEXED,
+      "lat" UNINDEXED,
+      "id" UNINDEXED,
+      "properties" UNINDEXED,
+      "geometry" UNINDEXED,
+      "layer" UNINDEXED,
+      "tile" UNINDEXED,
+      tokenize = "trigram")`,
+	`DROP TABLE IF EXISTS "metadata"`,
+	`CREATE TABLE "metadata" ("name" TEXT PRIMARY KEY, "value" TEXT)`,
+	fmt.Sprintf(`PRAGMA user_version = %d`, placeIndexVersion),
+}
+
+// ErrPlaceIndexVersion of index built by other version
+var ErrPlaceIndexVersion = errors.New("place index has to be rebuilt")
+
+// PlaceIndex of place names, classes and coordinates in SQLite FTS5 database
+type PlaceIndex struct {
+	db *sqlx.DB
+
+	// Indexed layers, name keys and zoom level of the search config
+	layers   []string
+	nameKeys []string
+	zoom     int
+}
+
+// IndexPath of place index sidecar database next to MBTiles file
+func IndexPath(mbtilesPath string) string {
+	return strings.TrimSuffix(mbtilesPath, filepath.Ext(mbtilesPath)) + ".places.db"
+}
+
+// OpenPlaceIndex database, it must exist and be of the current version
+func OpenPlaceIndex(path string) (*PlaceIndex, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	var params = url.Values{}
+	params.Add("_query_only", "true")
+	db, err := sqlx.Open("sqlite3", path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var version int
+	if err := db.Get(&version, `PRAGMA user_version`); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if version != placeIndexVersion {
+		_ = db.Close()
+		return nil, ErrPlaceIndexVersion
+	}
+	idx := &PlaceIndex{db: db}
+	if err := idx.readMetadata(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+// readMetadata of indexed search config
+func (idx *PlaceIndex) readMetadata() error {
+	rows, err := idx.db.Queryx(`SELECT "name", "value" FROM "metadata"`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return err
+		}
+		switch name {
+		case "layers":
+			err = json.Unmarshal([]byte(value), &idx.layers)
+		case "name_keys":
+			err = json.Unmarshal([]byte(value), &idx.nameKeys)
+		case "zoom":
+			err = json.Unmarshal([]byte(value), &idx.zoom)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// writeMetadata of indexed search config
+func writeMetadata(tx *sqlx.Tx, cfg SearchConfig, zoom int) error {
+	for name, value := range map[string]interface{}{
+		"layers":    uniqueStrings(cfg.Layers),
+		"name_keys": cfg.matchKeys(),
+		"zoom":      zoom,
+	} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO "metadata" ("name", "value") VALUES (?, ?)`, name, string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexes places of the config at zoom level, layers and name keys are compared in any order
+func (idx *PlaceIndex) indexes(cfg SearchConfig, zoom int) bool {
+	return idx.zoom == zoom && sameStrings(idx.layers, uniqueStrings(cfg.Layers)) && sameStrings(idx.nameKeys, cfg.matchKeys())
+}
+
+// sameStrings sets
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, value := range a {
+		seen[value] = true
+	}
+	for _, value := range b {
+		if !seen[value] {
+			return false
+		}
+	}
+	return true
+}
+
+// isPlaceIndexStale if it's older than MBTiles file
+func isPlaceIndexStale(mbtilesPath string, indexPath string) bool {
+	tiles, err := os.Stat(mbtilesPath)
+	if err != nil {
+		return false
+	}
+	index, err := os.Stat(indexPath)
+	return err == nil && index.ModTime().Before(tiles.ModTime())
+}
+
+// Close index database
+func (idx *PlaceIndex) Close() error {
+	return idx.db.Close()
+}
+
+// BuildPlaceIndex writes places of the current search config into sidecar database and uses it for searching.
+// The index is used while layers, name keys and zoom of the config are the same.
+func (m *Manager) BuildPlaceIndex(path string) error {
+	m.setPlaceIndex(nil)
+	cfg := m.SearchConfig()
+	zoom, err := m.searchZoom(cfg)
+	if err != nil {
+		return err
+	}
+
+	var params = url.Values{}
+	params.Add("_sync", "OFF")
+	params.Add("_journal", "MEMORY")
+	db, err := sqlx.Open("sqlite3", path+"?"+params.Encode())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, query := range placeIndexSchema {
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if err := writeMetadata(tx, cfg, zoom); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	count := 0
+	found := placeSet{}
+	nameKeys := cfg.matchKeys()
+	var insertErr error
+	err = m.walkThroughPlaces(cfg, nil, func(place *Place) bool {
+		if _, ok := found.add(place); !ok {
+			return true
+		}
+		names := placeNames(place.Properties, nameKeys)
+		if insertErr = insertPlace(tx, place, names); insertErr != nil {
+			return false
+		}
+		count++
+		return true
+	})
+	if err == nil {
+		err = insertErr
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	logrus.
+		WithField("path", path).
+		WithField("count", count).
+		Info("Build place index")
+
+	index, err := OpenPlaceIndex(path)
+	if err != nil {
+		return err
+	}
+	m.setPlaceIndex(index)
+	return nil
+}
+
+// insertPlace into index, all names are searchable in any language
+func insertPlace(tx *sqlx.Tx, place *Place, names []string) error {
+	properties, err := json.Marshal(place.Properties)
+	if err != nil {
+		return err
+	}
+	geometry, err := json.Marshal(geojson.NewGeometry(place.Geometry))
+	if err != nil {
+		return err
+	}
+	id, err := json.Marshal(place.ID)
+	if err != nil {
+		return err
+	}
+	tile, err := json.Marshal(place.Tile)
+	if err != nil {
+		return err
+	}
+	normalized := make([]string, len(names))
+	for i, name := range names {
+		normalized[i] = normalizeName(name)
+	}
+	_, err = tx.Exec(`
+      INSERT INTO "places" ("name", "names", "class", "lon", "lat", "id", "properties", "geometry", "layer", "tile")
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		place.Name, strings.Join(normalized, "\n"), place.Class, place.Center.Lon(), place.Center.Lat(),
+		string(id), string(properties), string(geometry), place.Layer, string(tile))
+	return err
+}
+
+// WalkThroughPlaces which names have all query words and call callback by each of them.
+// All places are walked through if query has no words.
+// Labels and classes are of the config, e.g. Manager.SearchConfig of the indexed file.
+func (idx *PlaceIndex) WalkThroughPlaces(query string, cfg SearchConfig, callback func(subj string, cls string, feature *geojson.Feature) bool) error {
+	return idx.walkThroughPlaces(query, nil, cfg, func(place *Place) bool {
+		return callback(place.Name, place.Class, place.Feature())
+	})
+}
+
+// walkThroughPlaces matching query which centers are within the bound unless it's nil
+func (idx *PlaceIndex) walkThroughPlaces(query string, bound *orb.Bound, cfg SearchConfig, callback func(place *Place) bool) error {
+	var conditions []string
+	var args []interface{}
+
+	// Trigram index is used by LIKE of words having 3 letters at least
+	for _, word := range strings.Fields(normalizeName(query)) {
+		conditions = append(conditions, `"names" LIKE ?`)
+		args = append(args, "%"+word+"%")
+	}
+	if bound != nil {
+		conditions = append(conditions, `"lon" BETWEEN ? AND ?`, `"lat" BETWEEN ? AND ?`)
+		args = append(args, bound.Min.Lon(), bound.Max.Lon(), bound.Min.Lat(), bound.Max.Lat())
+	}
+
+	sql := `SELECT "id", "properties", "geometry", "layer", "tile" FROM "places"`
+	if len(conditions) > 0 {
+		sql += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := idx.db.Queryx(sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, properties, geometry, layer, tile string
+		if err := rows.Scan(&id, &properties, &geometry, &layer, &tile); err != nil {
+			return err
+		}
+		place, err := decodePlace(cfg, id, properties, geometry, layer, tile)
+		if err != nil {
+			return err
+		}
+		if !callback(place) {
+			return nil
+		}
+	}
+	return rows.Err()
+}
+
+// decodePlace from index row
+func decodePlace(cfg SearchConfig, id string, properties string, geometry string, layer string, tile string) (*Place, error) {
+	var g geojson.Geometry
+	if err := json.Unmarshal([]byte(geometry), &g); err != nil {
+		return nil, err
+	}
+	if g.Coordinates == nil {
+		return nil, errors.New("place geometry is empty")
+	}
+	feature := geojson.NewFeature(g.Coordinates)
+	if err := json.Unmarshal([]byte(properties), &feature.Properties); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(id), &feature.ID); err != nil {
+		return nil, err
+	}
+	var mapTile maptile.Tile
+	if err := json.Unmarshal([]byte(tile), &mapTile); err != nil {
+		return nil, err
+	}
+	return cfg.newPlace(feature, layer, mapTile), nil
+}
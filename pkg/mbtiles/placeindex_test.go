@@ -0,0 +1,179 @@
+package mbtiles
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestPlaceIndex or skip the test if SQLite is built without FTS5
+func buildTestPlaceIndex(t *testing.T, m *Manager, path string) {
+	err := m.BuildPlaceIndex(IndexPath(path))
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		t.Skip("go-sqlite3 is built without fts5 tag")
+	}
+	require.NoError(t, err)
+}
+
+func TestPlaceIndex(t *testing.T) {
+	path := writeTestTiles(t, testPlaceFeatures)
+	m, err := NewManager(path)
+	require.NoError(t, err)
+	buildTestPlaceIndex(t, m, path)
+
+	places, err := m.Search("santa", 10)
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+
+	// Diacritics are ignored and coordinates are in WGS 84
+	places, err = m.Search("ursula", 10)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Santa Úrsula", places[0].Properties["name:latin"])
+	require.InDelta(t, -16.4891, places[0].Center.Lon(), 0.001)
+	require.InDelta(t, 28.4260, places[0].Center.Lat(), 0.001)
+
+	// Sidecar index is opened with MBTiles file
+	reopened, err := NewManager(path)
+	require.NoError(t, err)
+	require.NotNil(t, reopened.index)
+	places, err = reopened.Search("puerto cru", 10)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+
+	// Substrings are found as by scanning tiles
+	scanned, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+	for _, query := range []string{"ruz", "cristobal laguna", "ta", "de la"} {
+		indexed, err := reopened.Search(query, 10)
+		require.NoError(t, err)
+		places, err := scanned.Search(query, 10)
+		require.NoError(t, err)
+		require.Equal(t, placeNamesOf(places), placeNamesOf(indexed), query)
+	}
+
+	// Labels and classes are of the given config
+	cfg := DefaultSearchConfig()
+	cfg.Languages = []string{"latin"}
+	cfg.ClassKeys = []string{"rank"}
+	var labels []string
+	require.NoError(t, reopened.index.WalkThroughPlaces("ursula", cfg, func(subj string, cls string, feature *geojson.Feature) bool {
+		labels = append(labels, subj+" "+cls)
+		return true
+	}))
+	require.Equal(t, []string{"Santa Úrsula "}, labels)
+
+	// Stale index isn't used
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(IndexPath(path), old, old))
+	reopened, err = NewManager(path)
+	require.NoError(t, err)
+	require.Nil(t, reopened.index)
+}
+
+func TestPlaceIndexVersion(t *testing.T) {
+	path := writeTestTiles(t, testPlaceFeatures)
+	m, err := NewManager(path)
+	require.NoError(t, err)
+	buildTestPlaceIndex(t, m, path)
+
+	db, err := sqlx.Open("sqlite3", IndexPath(path))
+	require.NoError(t, err)
+	_, err = db.Exec(`PRAGMA user_version = 1`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = OpenPlaceIndex(IndexPath(path))
+	require.ErrorIs(t, err, ErrPlaceIndexVersion)
+	reopened, err := NewManager(path)
+	require.NoError(t, err)
+	require.Nil(t, reopened.index)
+}
+
+func TestPlaceIndexConfig(t *testing.T) {
+	features := append([]testFeature{
+		{layer: "poi", zoom: 14, geometry: orb.Point{-16.2500, 28.4680}, properties: geojson.Properties{
+			"name": "Farmacia Castillo", "class": "pharmacy",
+		}},
+	}, testLanguageFeatures...)
+	path := writeTestTiles(t, features)
+	m, err := NewManager(path)
+	require.NoError(t, err)
+	buildTestPlaceIndex(t, m, path)
+	require.NotNil(t, m.placeIndexOf(m.SearchConfig()))
+
+	// Index of other layers or names isn't used, tiles are scanned
+	cfg := DefaultSearchConfig()
+	cfg.Layers = []string{"poi"}
+	m.SetSearchConfig(cfg)
+	require.Nil(t, m.placeIndexOf(cfg))
+	places, err := m.Search("farmacia", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+
+	cfg = DefaultSearchConfig()
+	cfg.Languages = []string{"es"}
+	m.SetSearchConfig(cfg)
+	require.Nil(t, m.placeIndexOf(cfg))
+	places, err = m.Search("islas", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+
+	cfg = DefaultSearchConfig()
+	cfg.Zoom = 13
+	require.Nil(t, m.placeIndexOf(cfg))
+
+	// Index is used again with the config it's built for, reopened one as well
+	cfg = DefaultSearchConfig()
+	cfg.ClassKeys = []string{"subclass", "class"}
+	m.SetSearchConfig(cfg)
+	require.NotNil(t, m.placeIndexOf(cfg))
+	reopened, err := NewManager(path)
+	require.NoError(t, err)
+	require.NotNil(t, reopened.placeIndexOf(reopened.SearchConfig()))
+}
+
+func TestBuildPlaceIndexConcurrently(t *testing.T) {
+	path := writeTestTiles(t, testPlaceFeatures)
+	m, err := NewManager(path)
+	require.NoError(t, err)
+	buildTestPlaceIndex(t, m, path)
+
+	// Searches use the old, the new index or tiles while it's rebuilt, race detector checks the access
+	done := make(chan error)
+	go func() {
+		done <- m.BuildPlaceIndex(IndexPath(path))
+	}()
+	for i := 0; i < 20; i++ {
+		places, err := m.Search("laguna", 1)
+		require.NoError(t, err)
+		require.Len(t, places, 1)
+	}
+	require.NoError(t, <-done)
+	require.NotNil(t, m.placeIndex())
+}
+
+// placeNamesOf places in their order
+func placeNamesOf(places Places) []string {
+	names := []string{}
+	for _, place := range places {
+		names = append(names, place.Name)
+	}
+	return names
+}
+
+func TestSearchWithoutPlaceIndex(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+	require.Nil(t, m.index)
+
+	places, err := m.Search("santa", 10)
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+}
@@ -0,0 +1,91 @@
+package mbtiles
+
+import (
+	"context"
+	"strings"
+
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+)
+
+// POILayer of points of interest in OpenMapTiles schema
+const POILayer = "poi"
+
+// poiCategoryKeys of POI properties matched by categories
+var poiCategoryKeys = []string{"class", "subclass"}
+
+// SearchPOI of categories, e.g. "pharmacy" or "restaurant", which names have a query, ordered by relevance.
+// All categories are found if there are none, unnamed points are found by empty query only.
+func (m *Manager) SearchPOI(query string, categories []string, opts SearchOptions) (Places, error) {
+	cfg := m.SearchConfig()
+	mt, err := newNameMatcher(query, cfg)
+	if err != nil {
+		return nil, err
+	}
+	zoom, err := m.searchZoom(cfg)
+	if err != nil {
+		return nil, err
+	}
+	emptyQuery := strings.TrimSpace(query) == ""
+	wanted := map[string]bool{}
+	for _, category := range categories {
+		wanted[strings.ToLower(category)] = true
+	}
+
+	// POI class is more specific than configured class keys
+	cfg.ClassKeys = []string{"subclass", "class"}
+	nameKeys := cfg.matchKeys()
+
+	filter := NewZoomFilter(zoom)
+	filter.Bound = opts.Bound
+	found := placeSet{}
+	ranked := &rankedPlaces{limit: opts.Limit}
+	err = m.WalkTileLayers(context.Background(), filter, func(tile *Tile, layer *mvt.Layer) bool {
+		if layer.Name != POILayer {
+			return true
+		}
+		mapTile := tile.MapTile()
+		layer.ProjectToWGS84(mapTile)
+		for _, feature := range layer.Features {
+			if len(wanted) > 0 && !hasCategory(feature.Properties, wanted) {
+				continue
+			}
+			matchScore := SubstringMatchScore
+			if !emptyQuery {
+				var ok bool
+				if matchScore, ok = matchNames(mt, placeNames(feature.Properties, nameKeys)); !ok {
+					continue
+				}
+			}
+
+			place := cfg.newPlace(feature, layer.Name, mapTile)
+			if opts.Bound != nil && !opts.Bound.Contains(place.Center) {
+				continue
+			}
+			place.Score = placeScore(matchScore, place.Class, place.Properties)
+			if opts.Focus != nil {
+				distance := geo.Distance(*opts.Focus, place.Center)
+				place.Distance = &distance
+				place.Score = focusScore(place.Score, distance)
+			}
+
+			// Nearer copy replaces the kept one, which is ranked again
+			if kept, ok := found.add(place); ok {
+				ranked.add(kept)
+			}
+		}
+		return true
+	})
+	return m.withContext(ranked.result(), err)
+}
+
+// hasCategory of POI class or subclass
+func hasCategory(properties geojson.Properties, categories map[string]bool) bool {
+	for _, key := range poiCategoryKeys {
+		if value, ok := properties[key].(string); ok && categories[strings.ToLower(value)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+// testPOIFeatures of Santa Cruz de Tenerife and La Laguna
+var testPOIFeatures = []testFeature{
+	{layer: "poi", zoom: 14, geometry: orb.Point{-16.2530, 28.4650}, properties: geojson.Properties{
+		"name": "Farmacia Central", "class": "pharmacy", "subclass": "pharmacy", "rank": 5.0,
+	}},
+	{layer: "poi", zoom: 14, geometry: orb.Point{-16.2560, 28.4700}, properties: geojson.Properties{
+		"class": "pharmacy", "subclass": "chemist", "rank": 9.0,
+	}},
+	{layer: "poi", zoom: 14, geometry: orb.Point{-16.3160, 28.4870}, properties: geojson.Properties{
+		"name": "Farmacia La Laguna", "class": "pharmacy", "subclass": "pharmacy", "rank": 5.0,
+	}},
+	{layer: "poi", zoom: 14, geometry: orb.Point{-16.2520, 28.4660}, properties: geojson.Properties{
+		"name": "El Guanche", "class": "restaurant", "subclass": "restaurant", "rank": 3.0,
+	}},
+}
+
+func TestSearchPOI(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPOIFeatures))
+	require.NoError(t, err)
+
+	// Pharmacies near the point, unnamed ones as well
+	places, err := m.SearchPOI("", []string{"pharmacy"}, SearchOptions{Limit: 5, Focus: &orb.Point{-16.2531, 28.4651}})
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+	require.Equal(t, "Farmacia Central", places[0].Name)
+	require.Equal(t, "Farmacia La Laguna", places[2].Name)
+	require.Equal(t, POILayer, places[0].Layer)
+
+	// Subclass is the class
+	places, err = m.SearchPOI("", []string{"Chemist"}, SearchOptions{Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "chemist", places[0].Class)
+
+	// Restaurant by name
+	places, err = m.SearchPOI("guanche", []string{"restaurant"}, SearchOptions{Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	places, err = m.SearchPOI("guanche", []string{"pharmacy"}, SearchOptions{Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, places)
+
+	// All categories within the bound
+	bound := &orb.Bound{Min: orb.Point{-16.33, 28.48}, Max: orb.Point{-16.30, 28.49}}
+	places, err = m.SearchPOI("farmacia", nil, SearchOptions{Limit: 5, Bound: bound})
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Farmacia La Laguna", places[0].Name)
+}
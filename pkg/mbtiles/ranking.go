@@ -0,0 +1,214 @@
+package mbtiles
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/paulmach/orb/geojson"
+	"golang.org/x/text/language"
+	"golang.org/x/text/search"
+)
+
+// ScoreProperty of found feature, the higher the more relevant
+const ScoreProperty = "score"
+
+// Match kinds scores
+const (
+	ExactMatchScore      = 1.0
+	PrefixMatchScore     = 0.8
+	WordPrefixMatchScore = 0.6
+	SubstringMatchScore  = 0.4
+)
+
+// Score weights of match kind, class and importance of the place
+const (
+	matchWeight      = 0.6
+	classWeight      = 0.3
+	importanceWeight = 0.1
+)
+
+// Proximity to focus point of search
+const (
+	// FocusWeight of proximity in score, the rest is relevance
+	FocusWeight = 0.5
+
+	// FocusDistance in meters where proximity is a half
+	FocusDistance = 20000.0
+)
+
+// ClassRanks of place classes, the more important the higher
+var ClassRanks = map[string]float64{
+	"continent":         1,
+	"country":           1,
+	"state":             0.9,
+	"province":          0.9,
+	"island":            0.85,
+	"city":              0.8,
+	"town":              0.6,
+	"village":           0.4,
+	"suburb":            0.35,
+	"quarter":           0.3,
+	"neighbourhood":     0.25,
+	"hamlet":            0.2,
+	"locality":          0.15,
+	"isolated_dwelling": 0.1,
+}
+
+// matcher of names with collation ignoring case, diacritics and width
+type matcher struct {
+	pattern *search.Pattern
+
+	// Patterns of query words, which may be found in any order
+	words []*search.Pattern
+
+	empty bool
+}
+
+// newMatcher of query
+func newMatcher(query string) *matcher {
+	options := []search.Option{
+		// Loose causes case, diacritics and width to be ignored.
+		search.Loose,
+		// IgnoreDiacritics causes diacritics to be ignored ("ö" == "o").
+		search.IgnoreDiacritics,
+		// IgnoreWidth equates narrow with wide variants.
+		search.IgnoreWidth,
+	}
+	m := search.New(language.Und, options...)
+	mt := &matcher{
+		pattern: m.CompileString(query),
+		empty:   query == "",
+	}
+	if words := strings.Fields(query); len(words) > 1 {
+		for _, word := range words {
+			mt.words = append(mt.words, m.CompileString(word))
+		}
+	}
+	return mt
+}
+
+// match name, returns the best match kind score.
+// Empty query matches any name.
+func (mt *matcher) match(name string) (float64, bool) {
+	if mt.empty {
+		return SubstringMatchScore, true
+	}
+	if score := matchPattern(mt.pattern, name); score > 0 {
+		return score, true
+	}
+
+	// Every word has to be found, the worst of them scores
+	if len(mt.words) == 0 {
+		return 0, false
+	}
+	worst := WordPrefixMatchScore
+	for _, word := range mt.words {
+		score := matchPattern(word, name)
+		if score == 0 {
+			return 0, false
+		}
+		worst = math.Min(worst, score)
+	}
+	return worst, true
+}
+
+// matchPattern in name, returns the best match kind score or zero
+func matchPattern(pattern *search.Pattern, name string) float64 {
+	best := 0.0
+	for offset := 0; offset < len(name); {
+		start, end := pattern.IndexString(name[offset:])
+		if start < 0 || end <= 0 {
+			break
+		}
+		start, end = start+offset, end+offset
+
+		score := SubstringMatchScore
+		switch {
+		case start == 0 && end == len(name):
+			score = ExactMatchScore
+		case start == 0:
+			score = PrefixMatchScore
+		case isWordStart(name, start):
+			score = WordPrefixMatchScore
+		}
+		best = math.Max(best, score)
+
+		// Next match may be a word prefix
+		_, size := utf8.DecodeRuneInString(name[start:])
+		offset = start + size
+	}
+	return best
+}
+
+// isWordStart checks if a word starts at byte position of name
+func isWordStart(name string, position int) bool {
+	previous, _ := utf8.DecodeLastRuneInString(name[:position])
+	return !unicode.IsLetter(previous) && !unicode.IsNumber(previous)
+}
+
+// placeScore of matched place by match kind, class and importance
+func placeScore(matchScore float64, cls string, properties geojson.Properties) float64 {
+	return matchWeight*matchScore + classWeight*ClassRanks[cls] + importanceWeight*placeImportance(properties)
+}
+
+// focusScore boosted by proximity to the focus point
+func focusScore(score float64, distance float64) float64 {
+	proximity := FocusDistance / (FocusDistance + distance)
+	return (1-FocusWeight)*score + FocusWeight*proximity
+}
+
+// placeImportance from 0 to 1 by `rank` or `population` properties
+func placeImportance(properties geojson.Properties) float64 {
+	// OpenMapTiles rank starts from 1 for the most important places
+	if rank, ok := properties["rank"].(float64); ok && rank > 0 {
+		return 1 / rank
+	}
+	if population, ok := properties["population"].(float64); ok && population > 1 {
+		// 10 millions are the most important
+		return math.Min(math.Log10(population)/7, 1)
+	}
+	return 0
+}
+
+// rankedPlaces keeps the most relevant places by score, the best one if limit isn't positive
+type rankedPlaces struct {
+	limit  int
+	places Places
+}
+
+// add scored place, a place added again is kept once
+func (r *rankedPlaces) add(place *Place) {
+	r.places = append(r.places, place)
+	if len(r.places) >= 2*r.limit+64 {
+		r.truncate()
+	}
+}
+
+// result ordered by relevance
+func (r *rankedPlaces) result() Places {
+	r.truncate()
+	return r.places
+}
+
+// truncate places to the most relevant ones
+func (r *rankedPlaces) truncate() {
+	r.places.sortByScore()
+	unique := r.places[:0]
+	seen := map[*Place]bool{}
+	for _, place := range r.places {
+		if !seen[place] {
+			seen[place] = true
+			unique = append(unique, place)
+		}
+	}
+	r.places = unique
+	limit := r.limit
+	if limit < 1 {
+		limit = 1
+	}
+	if len(r.places) > limit {
+		r.places = r.places[:limit]
+	}
+}
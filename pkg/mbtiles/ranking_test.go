@@ -0,0 +1,65 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcher(t *testing.T) {
+	for name, expected := range map[string]float64{
+		"Santa":          ExactMatchScore,
+		"santa cruz":     PrefixMatchScore,
+		"Villa de Sánta": WordPrefixMatchScore,
+		"Abasantaria":    SubstringMatchScore,
+		"Santo":          0,
+	} {
+		score, ok := newMatcher("santa").match(name)
+		require.Equal(t, expected > 0, ok, name)
+		require.Equal(t, expected, score, name)
+	}
+
+	// Words are found in any order
+	score, ok := newMatcher("cruz puerto").match("Puerto de la Cruz")
+	require.True(t, ok)
+	require.Equal(t, WordPrefixMatchScore, score)
+	_, ok = newMatcher("cruz palmas").match("Puerto de la Cruz")
+	require.False(t, ok)
+
+	// The best of several matches
+	score, _ = newMatcher("cruz").match("Vera Cruzada de la Cruz")
+	require.Equal(t, WordPrefixMatchScore, score)
+}
+
+func TestSearchRanking(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+
+	places, err := m.Search("santa", 2)
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+	require.Equal(t, "Santa Cruz de Tenerife", places[0].Properties["name"])
+	require.Equal(t, "Santa Úrsula", places[1].Properties["name"])
+	require.Greater(t, places[0].Score, places[1].Score)
+
+	// Word prefix matches are ranked by class
+	places, err = m.Search("cruz", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+	require.Equal(t, "city", places[0].Properties["class"])
+	require.Equal(t, "Puerto de la Cruz", places[1].Properties["name"])
+
+	// Empty query finds all places by importance
+	places, err = m.Search("", 10)
+	require.NoError(t, err)
+	require.Len(t, places, len(testPlaceFeatures))
+	require.Equal(t, "hamlet", places[len(places)-1].Properties["class"])
+
+	// The best place is found without limit
+	for _, limit := range []int{0, -1} {
+		places, err = m.Search("santa", limit)
+		require.NoError(t, err)
+		require.Len(t, places, 1)
+		require.Equal(t, "Santa Cruz de Tenerife", places[0].Properties["name"])
+	}
+}
@@ -0,0 +1,124 @@
+package mbtiles
+
+import (
+	"context"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/maptile"
+	"github.com/paulmach/orb/planar"
+)
+
+// DistanceProperty of GeoJSON feature in meters to reverse geocoded or focus point
+const DistanceProperty = "distance"
+
+// ReverseOptions groups all cfg for Manager.Reverse
+type ReverseOptions struct {
+	// Maximal number of nearest places, 5 by default
+	Limit int
+
+	// Zoom level of tiles to look up, search zoom by default
+	Zoom int
+
+	// Layer of places, "place" by default
+	PlaceLayer string
+
+	// Layers of polygons which may contain the point, "boundary", "landuse" and "landcover" by default
+	PolygonLayers []string
+}
+
+// Reverse geocode a point.
+// Returns nearest places ordered by distance followed by polygons containing the point.
+func (m *Manager) Reverse(lon float64, lat float64, opts ReverseOptions) (Places, error) {
+	cfg := m.SearchConfig()
+	if opts.Limit < 1 {
+		opts.Limit = 5
+	}
+	if opts.Zoom < 1 {
+		zoom, err := m.searchZoom(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.Zoom = zoom
+	}
+	if opts.PlaceLayer == "" {
+		opts.PlaceLayer = "place"
+	}
+	if opts.PolygonLayers == nil {
+		opts.PolygonLayers = []string{"boundary", "landuse", "landcover"}
+	}
+	polygonLayers := map[string]bool{}
+	for _, name := range opts.PolygonLayers {
+		polygonLayers[name] = true
+	}
+
+	// Covering tile and its neighbours, places near tile edge may be in the next one
+	point := orb.Point{lon, lat}
+	tile := &Tile{ZoomLevel: int64(opts.Zoom)}
+	xyz := maptile.At(point, maptile.Zoom(opts.Zoom))
+	tile.Column = int64(xyz.X)
+	tile.Row = FlipRow(tile.ZoomLevel, int64(xyz.Y))
+	filter := TileFilter{
+		Zoom:   &TileRange{Min: tile.ZoomLevel, Max: tile.ZoomLevel},
+		Column: &TileRange{Min: tile.Column - 1, Max: tile.Column + 1},
+		Row:    &TileRange{Min: tile.Row - 1, Max: tile.Row + 1},
+	}
+
+	var places, polygons Places
+	found := placeSet{}
+	err := m.WalkTileLayers(context.Background(), filter, func(t *Tile, layer *mvt.Layer) bool {
+		isPolygonLayer := polygonLayers[layer.Name]
+		if layer.Name != opts.PlaceLayer && !isPolygonLayer {
+			return true
+		}
+		mapTile := t.MapTile()
+		layer.ProjectToWGS84(mapTile)
+		for _, feature := range layer.Features {
+			place := cfg.newPlace(feature, layer.Name, mapTile)
+			if isPolygonLayer {
+				// Polygons clipped by neighbour tiles contain the same point
+				if !polygonContains(feature.Geometry, point) {
+					continue
+				}
+				distance := 0.0
+				place.Distance = &distance
+				if kept, _ := found.addAt(place, point); kept == place {
+					polygons = append(polygons, place)
+				}
+				continue
+			}
+
+			// Same place can be copied in the buffer of neighbour tiles, the nearest copy is kept
+			distance := geo.Distance(point, place.Center)
+			place.Distance = &distance
+			if kept, _ := found.add(place); kept == place {
+				places = append(places, place)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(places, func(i, j int) bool {
+		return *places[i].Distance < *places[j].Distance
+	})
+	if len(places) > opts.Limit {
+		places = places[:opts.Limit]
+	}
+	return m.withContext(append(places, polygons...), nil)
+}
+
+// polygonContains the point, false for not polygonal geometries
+func polygonContains(geometry orb.Geometry, point orb.Point) bool {
+	switch g := geometry.(type) {
+	case orb.Polygon:
+		return planar.PolygonContains(g, point)
+	case orb.MultiPolygon:
+		return planar.MultiPolygonContains(g, point)
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverse(t *testing.T) {
+	santaUrsula := orb.Point{-16.4891, 28.4260}
+	residential := orb.Polygon{{
+		{-16.4930, 28.4230}, {-16.4850, 28.4230}, {-16.4850, 28.4290}, {-16.4930, 28.4290}, {-16.4930, 28.4230},
+	}}
+	neighbour := maptile.At(santaUrsula, 14)
+	neighbour.X++
+	m, err := NewManager(writeTestTiles(t, append([]testFeature{
+		{layer: "landuse", zoom: 14, geometry: residential, id: 7, properties: geojson.Properties{"class": "residential"}},
+		// Part of the polygon clipped by the buffer of the neighbour tile
+		{layer: "landuse", zoom: 14, geometry: orb.Polygon{{
+			{-16.4890, 28.4230}, {-16.4850, 28.4230}, {-16.4850, 28.4290}, {-16.4890, 28.4290}, {-16.4890, 28.4230},
+		}}, tile: &neighbour, id: 7, properties: geojson.Properties{"class": "residential"}},
+		// Overlapping polygons without ID and name are different ones
+		{layer: "landcover", zoom: 14, geometry: residential, properties: geojson.Properties{"class": "grass"}},
+		{layer: "landcover", zoom: 14, geometry: orb.Polygon{{
+			{-16.4900, 28.4250}, {-16.4870, 28.4250}, {-16.4870, 28.4280}, {-16.4900, 28.4280}, {-16.4900, 28.4250},
+		}}, properties: geojson.Properties{"class": "grass"}},
+		// Copy of a place in the buffer of the neighbour tile
+		{layer: "place", zoom: 14, geometry: santaUrsula, tile: &neighbour, properties: geojson.Properties{
+			"name": "Santa Úrsula", "name:latin": "Santa Úrsula", "class": "town", "rank": 9.0,
+		}},
+	}, testPlaceFeatures...)))
+	require.NoError(t, err)
+
+	places, err := m.Reverse(-16.4880, 28.4265, ReverseOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, places, 4)
+
+	require.Equal(t, "Santa Úrsula", places[0].Properties["name"])
+	require.Less(t, *places[0].Distance, 200.0)
+	require.InDelta(t, santaUrsula.Lon(), places[0].Center.Lon(), 0.001)
+
+	layers := map[string]int{}
+	for _, place := range places[1:] {
+		layers[place.Layer+" "+place.Class]++
+	}
+	require.Equal(t, map[string]int{"landuse residential": 1, "landcover grass": 2}, layers)
+
+	// Zoom of search config is used by default
+	cfg := m.SearchConfig()
+	cfg.Zoom = 13
+	m.SetSearchConfig(cfg)
+	places, err = m.Reverse(-16.4880, 28.4265, ReverseOptions{})
+	require.NoError(t, err)
+	require.Empty(t, places)
+	m.SetSearchConfig(DefaultSearchConfig())
+
+	// Nothing is in the sea
+	places, err = m.Reverse(-17.5, 28.0, ReverseOptions{})
+	require.NoError(t, err)
+	require.Empty(t, places)
+}
@@ -0,0 +1,307 @@
+package mbtiles
+
+import (
+	"context"
+	"errors"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geo"
+	"github.com/paulmach/orb/geojson"
+	"github.com/sirupsen/logrus"
+)
+
+// SearchMode of names matching
+type SearchMode string
+
+// List of search modes
+const (
+	// ExactMode finds names containing the query ignoring case, diacritics and width
+	ExactMode SearchMode = "exact"
+
+	// FuzzyMode finds names similar to the query by edit distance as well
+	FuzzyMode SearchMode = "fuzzy"
+
+	// PhoneticMode finds names sounding like the query by Spanish pronunciation as well
+	PhoneticMode SearchMode = "phonetic"
+)
+
+// ErrUnknownSearchMode an error of unknown search mode
+var ErrUnknownSearchMode = errors.New("unknown search mode")
+
+// nameMatcher scores matched names
+type nameMatcher interface {
+	match(name string) (float64, bool)
+}
+
+// SearchConfig of searchable layers and properties
+type SearchConfig struct {
+	// Layers to search in, e.g. "place", "poi" or "transportation_name"
+	Layers []string `mapstructure:"layers"`
+
+	// Name properties matched by search, the first existing one is displayed
+	// if none of Languages is available
+	NameKeys []string `mapstructure:"name-keys"`
+
+	// Languages of displayed name by priority, e.g. "es", "en" or "latin".
+	// Their names are matched as well.
+	Languages []string `mapstructure:"lang"`
+
+	// Class properties by priority, the first existing one is the class
+	ClassKeys []string `mapstructure:"class-keys"`
+
+	// Zoom level of tiles to scan, maxzoom of the tileset if zero
+	Zoom int `mapstructure:"zoom"`
+
+	// Set administrative context and display name to found places.
+	// All tiles at search zoom are scanned once to load the units.
+	Context bool `mapstructure:"context"`
+
+	// Layers of administrative polygons or OpenMapTiles boundary lines with admin_level property, e.g. "boundary"
+	ContextLayers []string `mapstructure:"context-layers"`
+
+	// Names matching mode, ExactMode by default
+	Mode SearchMode `mapstructure:"mode"`
+
+	// Minimal names similarity from 0 to 1 in FuzzyMode, DefaultFuzzyThreshold by default
+	FuzzyThreshold float64 `mapstructure:"fuzzy-threshold"`
+}
+
+// DefaultSearchConfig searches places of OpenMapTiles schema
+func DefaultSearchConfig() SearchConfig {
+	return SearchConfig{
+		Layers:         []string{"place"},
+		NameKeys:       []string{"name:latin", "name", "name_int", "name:en", "name_en", "name:de", "name_de"},
+		ClassKeys:      []string{"class"},
+		ContextLayers:  []string{"boundary"},
+		Mode:           ExactMode,
+		FuzzyThreshold: DefaultFuzzyThreshold,
+	}
+}
+
+// newNameMatcher of query by search mode
+func newNameMatcher(query string, cfg SearchConfig) (nameMatcher, error) {
+	switch cfg.Mode {
+	case ExactMode, "":
+		return newMatcher(query), nil
+	case FuzzyMode:
+		return newFuzzyMatcher(query, cfg.FuzzyThreshold), nil
+	case PhoneticMode:
+		return newPhoneticMatcher(query), nil
+	}
+	return nil, ErrUnknownSearchMode
+}
+
+// SetSearchConfig of searchable layers and properties.
+// Indexes of suggestions and context are rebuilt by the next call.
+// It's safe to call while searching, running searches keep the previous config.
+// Place index built for other layers, name keys or zoom isn't used with the config.
+func (m *Manager) SetSearchConfig(cfg SearchConfig) {
+	m.mu.Lock()
+	m.searchConfig = cfg
+	m.suggestions = &suggestIndex{cfg: cfg}
+	m.contexts = &contextIndex{cfg: cfg}
+	m.mu.Unlock()
+
+	if m.placeIndex() != nil && m.placeIndexOf(cfg) == nil {
+		logrus.Warn("Place index is built for other layers, names or zoom and isn't used, rebuild it")
+	}
+}
+
+// SearchConfig of searchable layers and properties
+func (m *Manager) SearchConfig() SearchConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.searchConfig
+}
+
+// searchIndexes of suggestions and context built for the current config
+func (m *Manager) searchIndexes() (*suggestIndex, *contextIndex) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.suggestions, m.contexts
+}
+
+// placeIndexOf places of the config, nil if it isn't built or indexes other places
+func (m *Manager) placeIndexOf(cfg SearchConfig) *PlaceIndex {
+	index := m.placeIndex()
+	if index == nil {
+		return nil
+	}
+	zoom, err := m.searchZoom(cfg)
+	if err != nil || !index.indexes(cfg, zoom) {
+		return nil
+	}
+	return index
+}
+
+// placeIndex used for searching, nil if it isn't built
+func (m *Manager) placeIndex() *PlaceIndex {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.index
+}
+
+// setPlaceIndex used for searching and close the previous one
+func (m *Manager) setPlaceIndex(index *PlaceIndex) {
+	m.mu.Lock()
+	previous := m.index
+	m.index = index
+	m.mu.Unlock()
+	if previous != nil {
+		_ = previous.Close()
+	}
+}
+
+// searchZoom configured or maxzoom of the tileset
+func (m *Manager) searchZoom(cfg SearchConfig) (int, error) {
+	if cfg.Zoom > 0 {
+		return cfg.Zoom, nil
+	}
+	if meta, err := m.GetMeta(); err == nil && meta.MaxZoom > 0 {
+		return meta.MaxZoom, nil
+	}
+	zoom, err := m.zoomRange(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return int(zoom.Max), nil
+}
+
+// WalkThroughPlaces of searchable layers and call callback if something was found.
+// Features are projected to WGS 84.
+func (m *Manager) WalkThroughPlaces(callback func(subj string, cls string, feature *geojson.Feature) bool) error {
+	return m.walkThroughPlaces(m.SearchConfig(), nil, func(place *Place) bool {
+		return callback(place.Name, place.Class, place.Feature())
+	})
+}
+
+// walkThroughPlaces of the config in tiles covering the bound, of all tiles if bound is nil
+func (m *Manager) walkThroughPlaces(cfg SearchConfig, bound *orb.Bound, callback func(place *Place) bool) error {
+	zoom, err := m.searchZoom(cfg)
+	if err != nil {
+		return err
+	}
+	nameKeys := cfg.matchKeys()
+	layers := map[string]bool{}
+	for _, name := range cfg.Layers {
+		layers[name] = true
+	}
+
+	filter := NewZoomFilter(zoom)
+	filter.Bound = bound
+	return m.WalkTileLayers(context.Background(), filter, func(tile *Tile, layer *mvt.Layer) bool {
+		if !layers[layer.Name] {
+			return true
+		}
+		mapTile := tile.MapTile()
+		layer.ProjectToWGS84(mapTile)
+		for _, feature := range layer.Features {
+			if firstStringProperty(feature.Properties, nameKeys) == "" {
+				continue
+			}
+			if !callback(cfg.newPlace(feature, layer.Name, mapTile)) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// firstStringProperty not empty value by keys priority
+func firstStringProperty(properties geojson.Properties, keys []string) string {
+	for _, key := range keys {
+		if value, ok := properties[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// SearchOptions groups all options for Manager.SearchWithOptions
+type SearchOptions struct {
+	// Maximal results number, the best place only if it isn't positive
+	Limit int
+
+	// Focus point, nearby places are boosted and get distance in meters
+	Focus *orb.Point
+
+	// Bound restricting places, tiles outside of it aren't decoded
+	Bound *orb.Bound
+}
+
+// Search places where subject has a query, ordered by relevance.
+// Place index is used if it's built, otherwise all places are scanned.
+func (m *Manager) Search(query string, maxResults int) (Places, error) {
+	return m.SearchWithOptions(query, SearchOptions{Limit: maxResults})
+}
+
+// SearchWithOptions of focus point and bound restriction
+func (m *Manager) SearchWithOptions(query string, opts SearchOptions) (Places, error) {
+	cfg := m.SearchConfig()
+	mt, err := newNameMatcher(query, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Misspelled words can't be found by prefixes
+	indexQuery := query
+	if cfg.Mode == FuzzyMode || cfg.Mode == PhoneticMode {
+		indexQuery = ""
+	}
+	walk := m.placeWalker(cfg, indexQuery, opts.Bound)
+
+	nameKeys := cfg.matchKeys()
+	found := placeSet{}
+	ranked := &rankedPlaces{limit: opts.Limit}
+	err = walk(func(place *Place) bool {
+		matchScore, ok := matchNames(mt, placeNames(place.Properties, nameKeys))
+		if !ok {
+			return true
+		}
+
+		// Tiles may cover more than the bound
+		if opts.Bound != nil && !opts.Bound.Contains(place.Center) {
+			return true
+		}
+
+		place.Score = placeScore(matchScore, place.Class, place.Properties)
+		if opts.Focus != nil {
+			distance := geo.Distance(*opts.Focus, place.Center)
+			place.Distance = &distance
+			place.Score = focusScore(place.Score, distance)
+		}
+
+		// Nearer copy replaces the kept one, which is ranked again
+		if kept, ok := found.add(place); ok {
+			ranked.add(kept)
+		}
+		return true
+	})
+	return m.withContext(ranked.result(), err)
+}
+
+// matchNames of place by the best of them
+func matchNames(mt nameMatcher, names []string) (float64, bool) {
+	best, found := 0.0, false
+	for _, name := range names {
+		if score, ok := mt.match(name); ok && (!found || score > best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// placeWalker of place index matching query words prefixes if it's built for the config,
+// otherwise of all places in tiles. Places of the config are restricted by the bound unless it's nil.
+func (m *Manager) placeWalker(cfg SearchConfig, query string, bound *orb.Bound) func(callback func(place *Place) bool) error {
+	index := m.placeIndexOf(cfg)
+	if index == nil {
+		return func(callback func(place *Place) bool) error {
+			return m.walkThroughPlaces(cfg, bound, callback)
+		}
+	}
+	return func(callback func(place *Place) bool) error {
+		return index.walkThroughPlaces(query, bound, cfg, callback)
+	}
+}
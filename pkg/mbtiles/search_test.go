@@ -2,6 +2,11 @@ package mbtiles
 
 import (
 	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSearchingByWord(t *testing.T) {
@@ -11,12 +16,112 @@ func TestSearchingByWord(t *testing.T) {
 	}
 
 	searchQuery := "santa"
-	features, err := manager.Search(searchQuery, 5)
+	places, err := manager.Search(searchQuery, 5)
 	if err != nil {
 		t.Error(err)
 	}
 
-	if len(features) < 1 {
+	if len(places) < 1 {
 		t.Errorf("nothing found")
 	}
 }
+
+func TestSearchResultsInWGS84(t *testing.T) {
+	santaCruz := testPlaceFeatures[0]
+	neighbour := maptile.At(santaCruz.geometry.(orb.Point), 14)
+	neighbour.Y++
+	santaCruz.tile = &neighbour
+	m, err := NewManager(writeTestTiles(t, append([]testFeature{santaCruz}, testPlaceFeatures...)))
+	require.NoError(t, err)
+
+	places, err := m.Search("Santa Cruz", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1, "copy from neighbour tile isn't merged")
+	require.InDelta(t, -16.2518, places[0].Center.Lon(), 0.0001)
+	require.InDelta(t, 28.4636, places[0].Center.Lat(), 0.0001)
+}
+
+func TestSearchConfig(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, append([]testFeature{
+		{layer: "poi", zoom: 12, geometry: orb.Point{-16.2540, 28.4680}, properties: geojson.Properties{
+			"name": "Farmacia Santa Cruz", "class": "pharmacy", "subclass": "pharmacy",
+		}},
+		{layer: "transportation_name", zoom: 12, geometry: orb.LineString{{-16.2530, 28.4650}, {-16.2500, 28.4660}}, properties: geojson.Properties{
+			"name": "Calle Castillo", "class": "tertiary",
+		}},
+	}, testPlaceFeatures...)))
+	require.NoError(t, err)
+
+	// Place layer is scanned at tileset maxzoom by default
+	places, err := m.Search("santa cruz", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "city", places[0].Properties["class"])
+
+	m.SetSearchConfig(SearchConfig{
+		Layers:    []string{"poi", "transportation_name"},
+		NameKeys:  []string{"name"},
+		ClassKeys: []string{"subclass", "class"},
+		Zoom:      12,
+	})
+	places, err = m.Search("santa cruz", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Farmacia Santa Cruz", places[0].Properties["name"])
+
+	places, err = m.Search("castillo", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.IsType(t, orb.LineString{}, places[0].Geometry)
+}
+
+func TestSearchWithOptions(t *testing.T) {
+	path := writeTestTiles(t, testPlaceFeatures)
+	m, err := NewManager(path)
+	require.NoError(t, err)
+
+	// Focus boosts the nearest place above more important ones
+	places, err := m.SearchWithOptions("santa", SearchOptions{Limit: 3, Focus: &orb.Point{-16.57, 28.38}})
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+	require.Equal(t, "Santa Bárbara", places[0].Properties["name"])
+	require.Less(t, *places[0].Distance, 1000.0)
+
+	// Places outside of bound are skipped with or without place index
+	bound := &orb.Bound{Min: orb.Point{-16.6, 28.3}, Max: orb.Point{-16.4, 28.5}}
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			buildTestPlaceIndex(t, m, path)
+		}
+		places, err = m.SearchWithOptions("santa", SearchOptions{Limit: 5, Bound: bound})
+		require.NoError(t, err)
+		require.Len(t, places, 2)
+		for _, place := range places {
+			require.True(t, bound.Contains(place.Center))
+		}
+	}
+}
+
+func TestSetSearchConfigConcurrently(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+
+	// Searches keep working while the config is replaced, race detector checks the access
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			cfg := DefaultSearchConfig()
+			cfg.Context = i%2 == 0
+			m.SetSearchConfig(cfg)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		places, err := m.Search("laguna", 1)
+		require.NoError(t, err)
+		require.Len(t, places, 1)
+		_, err = m.Suggest("san", 1)
+		require.NoError(t, err)
+	}
+	<-done
+}
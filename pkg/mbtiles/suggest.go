@@ -0,0 +1,102 @@
+package mbtiles
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// suggestEntry of prefix index, a normalized name tail from one of its words
+type suggestEntry struct {
+	key   string
+	place *Place
+	// Position of the word the key starts with
+	word int
+}
+
+// suggestIndex of normalized place names sorted for binary search by prefix
+type suggestIndex struct {
+	// Config of indexed names
+	cfg SearchConfig
+
+	once    sync.Once
+	entries []suggestEntry
+	err     error
+}
+
+// load all places into index once
+func (idx *suggestIndex) load(m *Manager) error {
+	idx.once.Do(func() {
+		found := placeSet{}
+		nameKeys := idx.cfg.matchKeys()
+		idx.err = m.placeWalker(idx.cfg, "", nil)(func(place *Place) bool {
+			if _, ok := found.add(place); !ok {
+				return true
+			}
+
+			// Each name is suggested in any language
+			for _, placeName := range placeNames(place.Properties, nameKeys) {
+				words := strings.Fields(normalizeName(placeName))
+				for i := range words {
+					idx.entries = append(idx.entries, suggestEntry{
+						key:   strings.Join(words[i:], " "),
+						place: place,
+						word:  i,
+					})
+				}
+			}
+			return true
+		})
+		sort.Slice(idx.entries, func(i, j int) bool {
+			return idx.entries[i].key < idx.entries[j].key
+		})
+	})
+	return idx.err
+}
+
+// Suggest places which names or their words start with prefix, ordered by relevance.
+// Prefix index is built in memory by the first call.
+func (m *Manager) Suggest(prefix string, limit int) (Places, error) {
+	suggestions, _ := m.searchIndexes()
+	if err := suggestions.load(m); err != nil {
+		return nil, err
+	}
+	prefix = normalizeName(prefix)
+	if prefix == "" {
+		return nil, nil
+	}
+
+	// Best score of each place matched by several words
+	entries := suggestions.entries
+	scores := map[*Place]float64{}
+	var places Places
+	for i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].key >= prefix
+	}); i < len(entries) && strings.HasPrefix(entries[i].key, prefix); i++ {
+		entry := entries[i]
+		matchScore := WordPrefixMatchScore
+		switch {
+		case entry.word == 0 && entry.key == prefix:
+			matchScore = ExactMatchScore
+		case entry.word == 0:
+			matchScore = PrefixMatchScore
+		}
+		score := placeScore(matchScore, entry.place.Class, entry.place.Properties)
+		if _, ok := scores[entry.place]; !ok {
+			places = append(places, entry.place)
+		}
+		if score > scores[entry.place] {
+			scores[entry.place] = score
+		}
+	}
+
+	// Indexed places are shared, scores are set to copies with their own properties
+	ranked := &rankedPlaces{limit: limit}
+	for _, place := range places {
+		suggestion := *place
+		suggestion.Properties = place.Properties.Clone()
+		suggestion.Score = scores[place]
+		ranked.add(&suggestion)
+	}
+	return m.withContext(ranked.result(), nil)
+}
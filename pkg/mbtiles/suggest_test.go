@@ -0,0 +1,46 @@
+package mbtiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	m, err := NewManager(writeTestTiles(t, testPlaceFeatures))
+	require.NoError(t, err)
+
+	places, err := m.Suggest("San", 3)
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+	require.Equal(t, "Santa Cruz de Tenerife", places[0].Properties["name"])
+	require.Greater(t, places[0].Score, places[1].Score)
+
+	// Words are suggested with diacritics folded
+	places, err = m.Suggest("urs", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	require.Equal(t, "Santa Úrsula", places[0].Properties["name"])
+
+	// Suggestions don't share properties with the index
+	places[0].Properties["name"] = "changed"
+	places, err = m.Suggest("urs", 5)
+	require.NoError(t, err)
+	require.Equal(t, "Santa Úrsula", places[0].Properties["name"])
+
+	// Whole name prefixes are ranked above word prefixes
+	places, err = m.Suggest("puerto de la c", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	places, err = m.Suggest("cruz", 5)
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+	require.Equal(t, "city", places[0].Properties["class"])
+
+	places, err = m.Suggest("xyz", 5)
+	require.NoError(t, err)
+	require.Empty(t, places)
+	places, err = m.Suggest(" ", 5)
+	require.NoError(t, err)
+	require.Empty(t, places)
+}
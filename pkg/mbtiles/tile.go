@@ -1,14 +1,10 @@
 package mbtiles
 
 import (
-	"bytes"
-	"compress/gzip"
-	"compress/zlib"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"math"
+
+	"github.com/paulmach/orb/maptile"
 )
 
 // ErrEmptyTileData error
@@ -28,7 +24,7 @@ type Tile struct {
 
 // IsEmpty data?
 func (t *Tile) IsEmpty() bool {
-	return len(t.Data) > 0
+	return len(t.Data) == 0
 }
 
 // GetPath of tile
@@ -38,10 +34,20 @@ func (t *Tile) GetPath() string {
 
 // GetFileName XYZtoEPSG
 func (t *Tile) GetFileName() int64 {
-	return int64(math.Pow(2, float64(t.ZoomLevel)) - 1 - float64(t.Row))
+	return FlipRow(t.ZoomLevel, t.Row)
+}
+
+// MapTile in XYZ scheme
+func (t *Tile) MapTile() maptile.Tile {
+	return maptile.New(uint32(t.Column), uint32(FlipRow(t.ZoomLevel, t.Row)), maptile.Zoom(t.ZoomLevel))
+}
+
+// FlipRow converts tile row between TMS and XYZ schemes at zoom level
+func FlipRow(zoom int64, row int64) int64 {
+	return int64(1)<<uint(zoom) - 1 - row
 }
 
-// GetFormat of a tile
+// GetFormat of a tile content
 func (t *Tile) GetFormat() (TileFormat, error) {
 	if t.IsEmpty() {
 		return UNKNOWN, ErrEmptyTileData
@@ -49,25 +55,22 @@ func (t *Tile) GetFormat() (TileFormat, error) {
 	return DetectTileFormat(t.Data)
 }
 
-// DetectTileFormat by data prefix
+// DetectTileFormat of content by data prefix
 func (t *Tile) DetectTileFormat() (TileFormat, error) {
 	return DetectTileFormat(t.Data)
 }
 
+// Detect content type and encoding of tile data
+func (t *Tile) Detect() (TileInfo, error) {
+	return DetectTile(t.Data)
+}
+
+// GetProtobuf of decompressed tile data
 func (t *Tile) GetProtobuf() ([]byte, error) {
-	var tileDataReader io.Reader
-	tileDataReader = bytes.NewReader(t.Data)
-	format, err := t.DetectTileFormat()
-	if err != nil {
+	// Encoding is enough to decompress content of not detected format
+	info, err := t.Detect()
+	if err != nil && !errors.Is(err, ErrUnknownTileFormat) {
 		return nil, err
 	}
-	// Read tile da
-	// Decompress depending on the format
-	switch format {
-	case GZIP:
-		tileDataReader, _ = gzip.NewReader(tileDataReader)
-	case ZLIB:
-		tileDataReader, _ = zlib.NewReader(tileDataReader)
-	}
-	return ioutil.ReadAll(tileDataReader)
+	return info.Decode(t.Data)
 }
@@ -0,0 +1,62 @@
+package mbtiles
+
+import "strings"
+
+// TileJSONVersion of built documents
+const TileJSONVersion = "3.0.0"
+
+// TileJSON document describing the tile set for map clients
+// see: https://github.com/mapbox/tilejson-spec/tree/master/3.0.0
+type TileJSON struct {
+	TileJSON     string        `json:"tilejson"`
+	Tiles        []string      `json:"tiles"`
+	VectorLayers []VectorLayer `json:"vector_layers,omitempty"`
+	Attribution  string        `json:"attribution,omitempty"`
+	Bounds       []float64     `json:"bounds,omitempty"`
+	Center       []float64     `json:"center,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	FillZoom     int           `json:"fillzoom,omitempty"`
+	MaxZoom      int           `json:"maxzoom"`
+	MinZoom      int           `json:"minzoom"`
+	Name         string        `json:"name,omitempty"`
+	Scheme       string        `json:"scheme,omitempty"`
+	Version      string        `json:"version,omitempty"`
+
+	// Tile data format and statistics aren't part of the spec, but are widely used by clients
+	Format    string     `json:"format,omitempty"`
+	TileStats *TileStats `json:"tilestats,omitempty"`
+}
+
+// TileJSON of the tile set served by {z}/{x}/{y}.{format} URL template below base URL
+func (meta *Meta) TileJSON(baseUrl string) *TileJSON {
+	format := meta.Format
+	if format == "" {
+		format = "pbf"
+	}
+	tiles := meta.Tiles
+	if baseUrl != "" || len(tiles) == 0 {
+		tiles = []string{strings.TrimSuffix(baseUrl, "/") + "/{z}/{x}/{y}." + format}
+	}
+	scheme := meta.Scheme
+	if scheme == "" {
+		scheme = "xyz"
+	}
+
+	return &TileJSON{
+		TileJSON:     TileJSONVersion,
+		Tiles:        tiles,
+		VectorLayers: meta.VectorLayers,
+		Attribution:  meta.Attribution,
+		Bounds:       meta.Bounds,
+		Center:       meta.Center,
+		Description:  meta.Description,
+		FillZoom:     meta.FillZoom,
+		MaxZoom:      meta.MaxZoom,
+		MinZoom:      meta.MinZoom,
+		Name:         meta.Name,
+		Scheme:       scheme,
+		Version:      meta.Version,
+		Format:       format,
+		TileStats:    meta.TileStats,
+	}
+}
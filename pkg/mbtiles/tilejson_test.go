@@ -0,0 +1,81 @@
+package mbtiles
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBounds(t *testing.T) {
+	bounds, err := parseBounds("-18.2, 27.6,-13.4,29.5")
+	require.NoError(t, err)
+	require.Equal(t, []float64{-18.2, 27.6, -13.4, 29.5}, bounds)
+
+	bounds, err = parseBounds("")
+	require.NoError(t, err)
+	require.Nil(t, bounds)
+
+	for _, value := range []string{"1,2,3", "-190,0,10,10", "10,10,0,0", "a,b,c,d", "NaN,0,1,1"} {
+		_, err = parseBounds(value)
+		require.Error(t, err, value)
+	}
+
+	center, err := parseCenter("-16.25,28.46,14")
+	require.NoError(t, err)
+	require.Equal(t, []float64{-16.25, 28.46, 14}, center)
+	_, err = parseCenter("-16.25,28.46,1.5")
+	require.Error(t, err)
+}
+
+func TestMetaTileJSON(t *testing.T) {
+	path := writeTestTiles(t, testPlaceFeatures)
+	manager, err := NewManager(path)
+	require.NoError(t, err)
+	meta, err := manager.GetMeta()
+	require.NoError(t, err)
+
+	tileJSON := meta.TileJSON("http://localhost/tiles/")
+	require.Equal(t, TileJSONVersion, tileJSON.TileJSON)
+	require.Equal(t, []string{"http://localhost/tiles/{z}/{x}/{y}.pbf"}, tileJSON.Tiles)
+	require.Equal(t, "pbf", tileJSON.Format)
+	require.Equal(t, []float64{-18.2, 27.6, -13.4, 29.5}, tileJSON.Bounds)
+	require.Len(t, tileJSON.VectorLayers, 1)
+}
+
+func TestWriteMeta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	writer, err := NewWriter(path, WriterSettings{})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteTile(0, 0, 0, []byte("\x89PNG\r\n\x1a\n")))
+	require.NoError(t, writer.Close())
+
+	// Missing json row and format are tolerated
+	manager, err := NewManager(path)
+	require.NoError(t, err)
+	meta, err := manager.GetMeta()
+	require.NoError(t, err)
+	require.Equal(t, "png", meta.Format)
+	require.Empty(t, meta.VectorLayers)
+	require.NoError(t, manager.Close())
+
+	min, max := 0.0, 10.0
+	meta.Name = "written"
+	meta.FillZoom = 5
+	meta.Attribution = "© OpenStreetMap contributors"
+	meta.TileStats = &TileStats{LayerCount: 1, Layers: []TileStatsLayer{{
+		Layer: "place", Count: 1, Geometry: "Point", AttributeCount: 1,
+		Attributes: []TileStatsAttribute{{Attribute: "rank", Count: 1, Type: "number", Values: []interface{}{4.0}, Min: &min, Max: &max}},
+	}}}
+	require.NoError(t, WriteMeta(path, meta))
+
+	manager, err = NewManager(path)
+	require.NoError(t, err)
+	written, err := manager.GetMeta()
+	require.NoError(t, err)
+	require.Equal(t, "written", written.Name)
+	require.Equal(t, 5, written.FillZoom)
+	require.Equal(t, meta.Attribution, written.Attribution)
+	require.Equal(t, meta.TileStats, written.TileStats)
+	require.Equal(t, "http://localhost/{z}/{x}/{y}.png", written.TileJSON("http://localhost").Tiles[0])
+}
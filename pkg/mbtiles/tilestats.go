@@ -0,0 +1,233 @@
+package mbtiles
+
+import (
+	"context"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+)
+
+// TileStatsMaxValues of attribute value samples
+const TileStatsMaxValues = 100
+
+// TileStatsMaxDistinct values of attribute kept and counted as mapbox-geostats does,
+// values beyond it are ignored
+const TileStatsMaxDistinct = 1000
+
+// Attribute types of tile statistics
+const (
+	stringAttribute  = "string"
+	numberAttribute  = "number"
+	booleanAttribute = "boolean"
+	mixedAttribute   = "mixed"
+)
+
+// layerStats collected at the highest zoom level the layer appears at,
+// where every feature is present once but for tile buffers.
+// Features copied into buffers of neighbour tiles are counted in each of them.
+type layerStats struct {
+	zoom       int64
+	count      int
+	geometries map[string]int
+	attributes map[string]*attributeStats
+}
+
+// attributeStats of distinct values
+type attributeStats struct {
+	types map[string]bool
+
+	// Distinct values up to TileStatsMaxDistinct
+	values map[interface{}]bool
+
+	min, max *float64
+}
+
+// TileStats of vector layers of tiles matching the filter.
+// Layers are analyzed at the highest zoom level they appear at.
+// Feature counts include copies of features in tile buffers,
+// distinct values are counted up to TileStatsMaxDistinct.
+func (m *Manager) TileStats(ctx context.Context, filter TileFilter) (*TileStats, error) {
+	layers := map[string]*layerStats{}
+	err := m.WalkTileLayers(ctx, filter, func(tile *Tile, layer *mvt.Layer) bool {
+		stats, ok := layers[layer.Name]
+		if ok && tile.ZoomLevel < stats.zoom {
+			return true
+		}
+		if !ok || tile.ZoomLevel > stats.zoom {
+			stats = &layerStats{
+				zoom:       tile.ZoomLevel,
+				geometries: map[string]int{},
+				attributes: map[string]*attributeStats{},
+			}
+			layers[layer.Name] = stats
+		}
+
+		for _, feature := range layer.Features {
+			stats.count++
+			if geometryType := tileStatsGeometry(feature.Geometry); geometryType != "" {
+				stats.geometries[geometryType]++
+			}
+			for key, value := range feature.Properties {
+				attribute, ok := stats.attributes[key]
+				if !ok {
+					attribute = &attributeStats{types: map[string]bool{}, values: map[interface{}]bool{}}
+					stats.attributes[key] = attribute
+				}
+				attribute.add(value)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TileStats{LayerCount: len(layers), Layers: []TileStatsLayer{}}
+	for name, stats := range layers {
+		result.Layers = append(result.Layers, stats.result(name))
+	}
+	sort.Slice(result.Layers, func(i, j int) bool {
+		return result.Layers[i].Layer < result.Layers[j].Layer
+	})
+	return result, nil
+}
+
+// add decoded value of feature property
+func (a *attributeStats) add(value interface{}) {
+	if number, ok := numberValue(value); ok {
+		a.types[numberAttribute] = true
+		a.addValue(number)
+		if a.min == nil || number < *a.min {
+			a.min = &number
+		}
+		if a.max == nil || number > *a.max {
+			max := number
+			a.max = &max
+		}
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		a.types[stringAttribute] = true
+		a.addValue(v)
+	case bool:
+		a.types[booleanAttribute] = true
+		a.addValue(v)
+	}
+}
+
+// addValue to distinct values until they're full
+func (a *attributeStats) addValue(value interface{}) {
+	if len(a.values) < TileStatsMaxDistinct {
+		a.values[value] = true
+	}
+}
+
+// result of layer statistics with the most frequent geometry type
+func (s *layerStats) result(name string) TileStatsLayer {
+	layer := TileStatsLayer{
+		Layer:          name,
+		Count:          s.count,
+		AttributeCount: len(s.attributes),
+		Attributes:     []TileStatsAttribute{},
+	}
+	for geometryType, count := range s.geometries {
+		if count > s.geometries[layer.Geometry] || (count == s.geometries[layer.Geometry] && geometryType < layer.Geometry) {
+			layer.Geometry = geometryType
+		}
+	}
+
+	for key, attribute := range s.attributes {
+		attributeType := mixedAttribute
+		if len(attribute.types) == 1 {
+			for t := range attribute.types {
+				attributeType = t
+			}
+		}
+		values := make([]interface{}, 0, len(attribute.values))
+		for value := range attribute.values {
+			values = append(values, value)
+		}
+		sortValues(values)
+		if len(values) > TileStatsMaxValues {
+			values = values[:TileStatsMaxValues]
+		}
+		layer.Attributes = append(layer.Attributes, TileStatsAttribute{
+			Attribute: key,
+			Count:     len(attribute.values),
+			Type:      attributeType,
+			Values:    values,
+			Min:       attribute.min,
+			Max:       attribute.max,
+		})
+	}
+	sort.Slice(layer.Attributes, func(i, j int) bool {
+		return layer.Attributes[i].Attribute < layer.Attributes[j].Attribute
+	})
+	return layer
+}
+
+// tileStatsGeometry type, multi geometries are of their single type
+func tileStatsGeometry(geometry orb.Geometry) string {
+	switch geometry.(type) {
+	case orb.Point, orb.MultiPoint:
+		return "Point"
+	case orb.LineString, orb.MultiLineString:
+		return "LineString"
+	case orb.Polygon, orb.MultiPolygon:
+		return "Polygon"
+	}
+	return ""
+}
+
+// numberValue of any decoded numeric type
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// sortValues of booleans, numbers and strings in this order
+func sortValues(values []interface{}) {
+	rank := func(value interface{}) int {
+		switch value.(type) {
+		case bool:
+			return 0
+		case float64:
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(values, func(i, j int) bool {
+		ri, rj := rank(values[i]), rank(values[j])
+		if ri != rj {
+			return ri < rj
+		}
+		switch vi := values[i].(type) {
+		case bool:
+			return !vi && values[j].(bool)
+		case float64:
+			return vi < values[j].(float64)
+		case string:
+			return vi < values[j].(string)
+		}
+		return false
+	})
+}
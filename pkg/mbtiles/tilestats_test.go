@@ -0,0 +1,65 @@
+package mbtiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTileStats(t *testing.T) {
+	features := append([]testFeature{
+		{layer: "place", zoom: 10, geometry: orb.Point{-16.2518, 28.4636}, properties: geojson.Properties{
+			"name": "Santa Cruz de Tenerife", "class": "city", "rank": 4.0,
+		}},
+		{layer: "poi", zoom: 14, geometry: orb.Point{-16.2518, 28.4636}, properties: geojson.Properties{
+			"class": "pharmacy", "indoor": true,
+		}},
+		{layer: "poi", zoom: 14, geometry: orb.Point{-16.2520, 28.4637}, properties: geojson.Properties{
+			"class": "bar", "indoor": "no",
+		}},
+	}, testPlaceFeatures...)
+	manager, err := NewManager(writeTestTiles(t, features))
+	require.NoError(t, err)
+
+	stats, err := manager.TileStats(context.Background(), TileFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.LayerCount)
+
+	// Places of lower zoom level aren't counted twice
+	place := stats.Layers[0]
+	require.Equal(t, "place", place.Layer)
+	require.Equal(t, len(testPlaceFeatures), place.Count)
+	require.Equal(t, "Point", place.Geometry)
+	require.Equal(t, 4, place.AttributeCount)
+	rank := place.Attributes[3]
+	require.Equal(t, "rank", rank.Attribute)
+	require.Equal(t, "number", rank.Type)
+	require.Equal(t, 5, rank.Count)
+	require.Equal(t, []interface{}{4.0, 5.0, 8.0, 9.0, 14.0}, rank.Values)
+	require.Equal(t, 4.0, *rank.Min)
+	require.Equal(t, 14.0, *rank.Max)
+
+	poi := stats.Layers[1]
+	require.Equal(t, 2, poi.Count)
+	require.Equal(t, []TileStatsAttribute{
+		{Attribute: "class", Count: 2, Type: "string", Values: []interface{}{"bar", "pharmacy"}},
+		{Attribute: "indoor", Count: 2, Type: "mixed", Values: []interface{}{true, "no"}},
+	}, poi.Attributes)
+}
+
+func TestAttributeStatsLimit(t *testing.T) {
+	attribute := &attributeStats{types: map[string]bool{}, values: map[interface{}]bool{}}
+	for i := 0; i < TileStatsMaxDistinct+10; i++ {
+		attribute.add(float64(i))
+		attribute.add(float64(i))
+	}
+
+	// Distinct values are counted up to the limit, numbers beyond it still update min and max
+	require.Len(t, attribute.values, TileStatsMaxDistinct)
+	stats := &layerStats{geometries: map[string]int{}, attributes: map[string]*attributeStats{"rank": attribute}}
+	require.Equal(t, TileStatsMaxDistinct, stats.result("place").Attributes[0].Count)
+	require.Equal(t, float64(TileStatsMaxDistinct+9), *attribute.max)
+}
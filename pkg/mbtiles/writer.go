@@ -0,0 +1,210 @@
+package mbtiles
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultBatchSize of tiles inserted in one transaction
+const DefaultBatchSize = 1000
+
+// schema of MBTiles 1.3 file
+// see: https://github.com/mapbox/mbtiles-spec/blob/master/1.3/spec.md
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS "metadata" ("name" text, "value" text)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "name" ON "metadata" ("name")`,
+	`CREATE TABLE IF NOT EXISTS "tiles" ("zoom_level" integer, "tile_column" integer, "tile_row" integer, "tile_data" blob)`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS "tile_index" ON "tiles" ("zoom_level", "tile_column", "tile_row")`,
+}
+
+// WriterSettings groups all cfg for NewWriter
+type WriterSettings struct {
+	// Number of tiles inserted in one transaction, DefaultBatchSize by default
+	BatchSize int
+}
+
+// Writer of MBTiles file.
+// It isn't safe for concurrent use.
+type Writer struct {
+	db  *sqlx.DB
+	tx  *sqlx.Tx
+	cfg WriterSettings
+
+	// Tiles count in current transaction
+	pending int
+}
+
+// NewWriter creates or opens MBTiles file to write tiles and metadata
+func NewWriter(path string, settings WriterSettings) (*Writer, error) {
+	var params = url.Values{}
+
+	// Writing is rather restarted than recovered on crash
+	params.Add("_sync", "OFF")
+	params.Add("_journal", "MEMORY")
+	db, err := sqlx.Open("sqlite3", path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// Transactions are bound to a connection
+	db.SetMaxOpenConns(1)
+	for _, query := range schema {
+		if _, err := db.Exec(query); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+
+	if settings.BatchSize < 1 {
+		settings.BatchSize = DefaultBatchSize
+	}
+	return &Writer{
+		db:  db,
+		cfg: settings,
+	}, nil
+}
+
+// WriteTile data, row is in TMS scheme as it's stored in the database.
+// Tiles of the current batch are discarded on error.
+func (w *Writer) WriteTile(zoom int64, column int64, row int64, data []byte) error {
+	if w.tx == nil {
+		var err error
+		if w.tx, err = w.db.Beginx(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.tx.Exec(`
+      INSERT OR REPLACE INTO "tiles" ("zoom_level", "tile_column", "tile_row", "tile_data")
+      VALUES (?, ?, ?, ?)`, zoom, column, row, data); err != nil {
+		// Partial batch isn't committed on Close
+		_ = w.tx.Rollback()
+		w.tx = nil
+		w.pending = 0
+		return err
+	}
+
+	w.pending++
+	if w.pending >= w.cfg.BatchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// WriteMeta into the metadata table, replacing existing values
+func (w *Writer) WriteMeta(meta *Meta) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return writeMeta(w.db, meta)
+}
+
+// WriteMeta into the metadata table of existing MBTiles file, tiles are kept intact
+func WriteMeta(path string, meta *Meta) error {
+	var params = url.Values{}
+	params.Add("_journal", "MEMORY")
+	db, err := sqlx.Open("sqlite3", path+"?"+params.Encode())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(schema[0]); err != nil {
+		return err
+	}
+	return writeMeta(db, meta)
+}
+
+// writeMeta rows in one transaction.
+// Rows are deleted before inserting as the name index may be missing.
+func writeMeta(db *sqlx.DB, meta *Meta) error {
+	rows, err := meta.metadataRows()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	for name, value := range rows {
+		if _, err := tx.Exec(`DELETE FROM "metadata" WHERE "name"=?`, name); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO "metadata" ("name", "value") VALUES (?, ?)`, name, value); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Flush commits pending tiles
+func (w *Writer) Flush() error {
+	if w.tx == nil {
+		return nil
+	}
+	err := w.tx.Commit()
+	w.tx = nil
+	w.pending = 0
+	return err
+}
+
+// Close the file, pending tiles are committed
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		_ = w.db.Close()
+		return err
+	}
+	return w.db.Close()
+}
+
+// metadataRows of MBTiles metadata table
+func (meta *Meta) metadataRows() (map[string]string, error) {
+	rows := map[string]string{
+		"name":    meta.Name,
+		"format":  meta.Format,
+		"minzoom": strconv.Itoa(meta.MinZoom),
+		"maxzoom": strconv.Itoa(meta.MaxZoom),
+	}
+	for name, value := range map[string]string{
+		"description": meta.Description,
+		"attribution": meta.Attribution,
+		"type":        meta.Type,
+		"version":     meta.Version,
+		"bounds":      floatArrayToString(meta.Bounds),
+		"center":      floatArrayToString(meta.Center),
+	} {
+		if value != "" {
+			rows[name] = value
+		}
+	}
+
+	if meta.FillZoom > 0 {
+		rows["fillzoom"] = strconv.Itoa(meta.FillZoom)
+	}
+
+	if len(meta.VectorLayers) > 0 || meta.TileStats != nil {
+		vectorJson, err := json.Marshal(struct {
+			VectorLayers []VectorLayer `json:"vector_layers,omitempty"`
+			TileStats    *TileStats    `json:"tilestats,omitempty"`
+		}{meta.VectorLayers, meta.TileStats})
+		if err != nil {
+			return nil, err
+		}
+		rows["json"] = string(vectorJson)
+	}
+	return rows, nil
+}
+
+func floatArrayToString(floats []float64) string {
+	var values = make([]string, len(floats))
+	for i, f := range floats {
+		values[i] = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strings.Join(values, ",")
+}